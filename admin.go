@@ -0,0 +1,249 @@
+// Copyright (c) 2019, Viet Tran, 200Lab Team.
+
+package goservice
+
+import (
+	"context"
+	"flag"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/200Lab-Education/go-sdk/util/metrics"
+	"github.com/200Lab-Education/go-sdk/util/secret"
+	"github.com/gin-gonic/gin"
+)
+
+type ComponentInfo struct {
+	Name     string         `json:"name"`
+	Prefix   string         `json:"prefix,omitempty"`
+	Kind     string         `json:"kind"`
+	State    ComponentState `json:"state"`
+	Error    string         `json:"error,omitempty"`
+	Restarts int            `json:"restarts,omitempty"`
+}
+
+type BuildInfo struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path"`
+	Version   string            `json:"version"`
+	Settings  map[string]string `json:"settings,omitempty"`
+	Deps      []string          `json:"deps,omitempty"`
+}
+
+type ServiceInfo struct {
+	Name      string     `json:"name"`
+	Version   string     `json:"version"`
+	Env       string     `json:"env"`
+	StartedAt time.Time  `json:"started_at"`
+	Uptime    string     `json:"uptime"`
+	Build     *BuildInfo `json:"build,omitempty"`
+}
+
+// Not admin-addr, it is the flag of an HTTP server with prefix "admin"
+const adminAddrFlagName = "admin-server-addr"
+
+// Admin endpoints for introspection, they are served on a separate
+// bind address and disabled by default
+type adminServer struct {
+	sv     *service
+	addr   string
+	svr    *http.Server
+	logger logger.Logger
+}
+
+func newAdminServer(sv *service) *adminServer {
+	return &adminServer{sv: sv}
+}
+
+func (a *adminServer) Name() string {
+	return "admin"
+}
+
+func (a *adminServer) InitFlags() {
+	flag.StringVar(&a.addr, adminAddrFlagName, "", "Bind address of admin endpoints. Ex: 127.0.0.1:9000. Disabled if empty")
+}
+
+func (a *adminServer) Configure() error {
+	a.logger = logger.GetCurrent().GetLogger(a.Name())
+
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	a.registerRoutes(engine.Group("/admin"))
+
+	if a.sv.metrics.path != "" && a.sv.metrics.server == "" {
+		engine.GET(a.sv.metrics.path, gin.WrapH(metrics.Default().Handler()))
+	}
+
+	a.svr = &http.Server{Handler: engine}
+	return nil
+}
+
+func (a *adminServer) Run() error {
+	if a.addr == "" {
+		return nil
+	}
+
+	if err := a.Configure(); err != nil {
+		return err
+	}
+
+	lis, err := net.Listen("tcp", a.addr)
+	if err != nil {
+		return err
+	}
+
+	a.logger.Infof("listen on %s...", lis.Addr().String())
+
+	if err := a.svr.Serve(lis); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
+
+func (a *adminServer) Stop() <-chan bool {
+	c := make(chan bool, 1)
+
+	go func() {
+		if a.svr != nil {
+			_ = a.svr.Shutdown(context.Background())
+		}
+		c <- true
+	}()
+
+	return c
+}
+
+// Implement ForceStopper interface
+func (a *adminServer) ForceStop() error {
+	if a.svr == nil {
+		return nil
+	}
+
+	return a.svr.Close()
+}
+
+func (a *adminServer) registerRoutes(group *gin.RouterGroup) {
+	group.GET("", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"service":    a.sv.info(),
+			"components": a.sv.components(),
+			"config":     a.sv.effectiveConfig(),
+		})
+	})
+
+	group.GET("/info", func(c *gin.Context) { c.JSON(http.StatusOK, a.sv.info()) })
+	group.GET("/components", func(c *gin.Context) { c.JSON(http.StatusOK, a.sv.components()) })
+	group.GET("/config", func(c *gin.Context) { c.JSON(http.StatusOK, a.sv.effectiveConfig()) })
+	// declared error keys with their status, ex: for API docs
+	group.GET("/errors", func(c *gin.Context) { c.JSON(http.StatusOK, sdkcm.ErrorCatalog()) })
+
+	group.GET("/log-level", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"level": a.sv.logger.GetLevel()})
+	})
+
+	group.PUT("/log-level", func(c *gin.Context) {
+		var body struct {
+			Level string `json:"level" form:"level"`
+		}
+
+		if err := c.ShouldBind(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := logger.GetCurrent().SetLevel(body.Level); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		a.logger.Infof("log level changed to %s", body.Level)
+		c.JSON(http.StatusOK, gin.H{"level": body.Level})
+	})
+}
+
+func (s *service) info() ServiceInfo {
+	info := ServiceInfo{
+		Name:      s.name,
+		Version:   s.version,
+		Env:       s.env,
+		StartedAt: s.startedAt,
+	}
+
+	if !s.startedAt.IsZero() {
+		info.Uptime = time.Since(s.startedAt).Round(time.Second).String()
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		build := &BuildInfo{
+			GoVersion: bi.GoVersion,
+			Path:      bi.Main.Path,
+			Version:   bi.Main.Version,
+			Settings:  map[string]string{},
+		}
+
+		for _, st := range bi.Settings {
+			build.Settings[st.Key] = st.Value
+		}
+
+		for _, dep := range bi.Deps {
+			build.Deps = append(build.Deps, dep.Path+"@"+dep.Version)
+		}
+
+		info.Build = build
+	}
+
+	return info
+}
+
+func (s *service) components() []ComponentInfo {
+	result := make([]ComponentInfo, 0, len(s.subServices)+len(s.initPrefixes))
+
+	for _, subService := range s.subServices {
+		result = append(result, s.componentInfo(subService, "runnable", ""))
+	}
+
+	for _, prefix := range s.initPrefixes {
+		result = append(result, s.componentInfo(s.initServices[prefix], "init", prefix))
+	}
+
+	return result
+}
+
+func (s *service) componentInfo(r Runnable, kind, prefix string) ComponentInfo {
+	status := s.states.get(r)
+
+	info := ComponentInfo{
+		Name:     r.Name(),
+		Prefix:   prefix,
+		Kind:     kind,
+		State:    status.state,
+		Restarts: status.restarts,
+	}
+
+	if status.err != nil {
+		info.Error = status.err.Error()
+	}
+
+	return info
+}
+
+// effectiveConfig returns value of all flags, secrets are masked
+func (s *service) effectiveConfig() map[string]string {
+	config := map[string]string{}
+
+	s.cmdLine.VisitAll(func(f *flag.Flag) {
+		if secret.IsSensitive(f) {
+			config[f.Name] = secret.Mask(flagValue(f))
+			return
+		}
+
+		config[f.Name] = flagValue(f)
+	})
+
+	return config
+}
@@ -0,0 +1,36 @@
+package goservice
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newIsolatedService creates a service with its own command line,
+// flags of New are global and cannot be defined twice
+func newIsolatedService(t *testing.T, args []string, opts ...Option) *service {
+	cmdLine, osArgs := flag.CommandLine, os.Args
+	t.Cleanup(func() { flag.CommandLine, os.Args = cmdLine, osArgs })
+
+	flag.CommandLine = flag.NewFlagSet("test", flag.ContinueOnError)
+	os.Args = append([]string{"test"}, args...)
+
+	return New(opts...).(*service)
+}
+
+func TestAdminFlagWithAdminHttpServer(t *testing.T) {
+	var s *service
+
+	assert.NotPanics(t, func() {
+		s = newIsolatedService(t, []string{"-admin-addr=127.0.0.1:0", "-admin-server-addr=127.0.0.1:9000"},
+			WithName("x"), WithHttpServer("admin"))
+	}, "admin http server flags should not clash with admin endpoints flag")
+
+	server, ok := s.HTTPServerWithPrefix("admin")
+	assert.True(t, ok, "should be registered")
+	assert.NotNil(t, server, "should not be nil")
+	assert.Equal(t, "127.0.0.1:0", flag.CommandLine.Lookup("admin-addr").Value.String(), "should be the http server flag")
+	assert.Equal(t, "127.0.0.1:9000", flag.CommandLine.Lookup(adminAddrFlagName).Value.String(), "should be the admin endpoints flag")
+}
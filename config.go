@@ -0,0 +1,202 @@
+package goservice
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/200Lab-Education/go-sdk/util/secret"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const configFlagName = "config"
+
+const (
+	ConfigFormatEnv  = "env"
+	ConfigFormatYAML = "yaml"
+	ConfigFormatJSON = "json"
+	ConfigFormatTOML = "toml"
+)
+
+var ErrConfigFormatNotSupported = errors.New("config format is not supported, use: env | yaml | json | toml")
+
+// configFormat guesses format of a config file by its extension
+func configFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ConfigFormatYAML, nil
+	case ".json":
+		return ConfigFormatJSON, nil
+	case ".toml":
+		return ConfigFormatTOML, nil
+	}
+
+	return "", ErrConfigFormatNotSupported
+}
+
+// readConfigFile returns values of a config file by flag names.
+// Nested keys are joined by dash, so
+//
+//	gorm:
+//	  db:
+//	    uri: ...
+//
+// is the value of flag gorm-db-uri. Flat keys (gorm-db-uri: ...) work too.
+func readConfigFile(path string) (map[string]string, error) {
+	format, err := configFormat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]interface{}{}
+
+	switch format {
+	case ConfigFormatYAML:
+		err = yaml.Unmarshal(data, &raw)
+	case ConfigFormatJSON:
+		err = json.Unmarshal(data, &raw)
+	case ConfigFormatTOML:
+		err = toml.Unmarshal(data, &raw)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	flattenConfig("", raw, values)
+
+	return values, nil
+}
+
+func flattenConfig(prefix string, raw map[string]interface{}, values map[string]string) {
+	for k, v := range raw {
+		name := strings.Replace(k, "_", "-", -1)
+		if prefix != "" {
+			name = prefix + "-" + name
+		}
+
+		switch val := v.(type) {
+		case map[string]interface{}:
+			flattenConfig(name, val, values)
+		case []interface{}:
+			items := make([]string, len(val))
+			for i := range val {
+				items[i] = fmt.Sprint(val[i])
+			}
+			values[name] = strings.Join(items, ",")
+		case nil:
+			values[name] = ""
+		default:
+			values[name] = fmt.Sprint(val)
+		}
+	}
+}
+
+// applyConfigFile sets flags from config file, flags which have been set
+// by env or command line are kept because they take precedence
+func (f *AppFlagSet) applyConfigFile(path string) error {
+	values, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	explicit := map[string]bool{}
+	f.Visit(func(fl *flag.Flag) { explicit[fl.Name] = true })
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fl := f.Lookup(name)
+		if fl == nil {
+			return fmt.Errorf("config file %s: unknown flag %s", path, name)
+		}
+
+		if explicit[name] || isEnvSet(name) {
+			continue
+		}
+
+		if err := setFlag(fl, values[name]); err != nil {
+			return fmt.Errorf("config file %s: %s", path, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// GetSampleConfig prints all flags as a flat config file in the given format
+func (f *AppFlagSet) GetSampleConfig(format string) error {
+	switch format {
+	case ConfigFormatEnv:
+		f.GetSampleEnvs()
+		return nil
+	case ConfigFormatJSON:
+		values := map[string]json.RawMessage{}
+		f.VisitAll(func(fl *flag.Flag) {
+			if isSampleExcluded(fl) {
+				return
+			}
+			values[fl.Name] = json.RawMessage(sampleValue(fl))
+		})
+
+		data, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	case ConfigFormatYAML, ConfigFormatTOML:
+		sep := ": "
+		if format == ConfigFormatTOML {
+			sep = " = "
+		}
+
+		f.VisitAll(func(fl *flag.Flag) {
+			if isSampleExcluded(fl) {
+				return
+			}
+
+			fmt.Printf("## %s\n%s%s%s\n\n", fl.Usage, fl.Name, sep, sampleValue(fl))
+		})
+		return nil
+	}
+
+	return ErrConfigFormatNotSupported
+}
+
+func isSampleExcluded(f *flag.Flag) bool {
+	return f.Name == "outenv" || f.Name == configFlagName
+}
+
+// sampleValue returns default value of a flag, quoted unless it is a bool or a number.
+// Quoted strings are valid in JSON, YAML and TOML. Secrets are left empty
+func sampleValue(f *flag.Flag) string {
+	if secret.IsSensitive(f) {
+		return `""`
+	}
+
+	if g, ok := f.Value.(flag.Getter); ok {
+		switch g.Get().(type) {
+		case bool, int, int64, uint, uint64, float64:
+			return f.DefValue
+		}
+	}
+
+	b, _ := json.Marshal(f.DefValue)
+	return string(b)
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2019, Viet Tran, 200Lab Team.
+
+package goservice
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sortInitServices returns init components ordered so that every component
+// comes after the components it depends on.
+// Components without dependencies keep their registration order.
+func (s *service) sortInitServices() ([]PrefixRunnable, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	marks := make(map[string]int, len(s.initPrefixes))
+	sorted := make([]PrefixRunnable, 0, len(s.initPrefixes))
+	var path []string
+
+	var visit func(prefix string) error
+	visit = func(prefix string) error {
+		switch marks[prefix] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for i := range path {
+				if path[i] == prefix {
+					start = i
+					break
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), prefix)
+			return fmt.Errorf("init components have a dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		marks[prefix] = visiting
+		path = append(path, prefix)
+
+		for _, dep := range s.dependenciesOf(prefix) {
+			if _, ok := s.initServices[dep]; !ok {
+				return fmt.Errorf("init component %s depends on unknown prefix %s", prefix, dep)
+			}
+
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		marks[prefix] = visited
+		sorted = append(sorted, s.initServices[prefix])
+		return nil
+	}
+
+	for _, prefix := range s.initPrefixes {
+		if err := visit(prefix); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+// dependenciesOf merges dependencies declared by the component itself
+// with the ones given to WithInitRunnable
+func (s *service) dependenciesOf(prefix string) []string {
+	deps := s.initDeps[prefix]
+
+	if hd, ok := s.initServices[prefix].(HasDependencies); ok {
+		deps = append(append([]string{}, deps...), hd.Dependencies()...)
+	}
+
+	return deps
+}
@@ -0,0 +1,78 @@
+package goservice
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type mockInitRunnable struct {
+	prefix string
+	deps   []string
+}
+
+func (m *mockInitRunnable) Name() string           { return m.prefix }
+func (m *mockInitRunnable) GetPrefix() string      { return m.prefix }
+func (m *mockInitRunnable) Get() interface{}       { return nil }
+func (m *mockInitRunnable) InitFlags()             {}
+func (m *mockInitRunnable) Configure() error       { return nil }
+func (m *mockInitRunnable) Run() error             { return nil }
+func (m *mockInitRunnable) Dependencies() []string { return m.deps }
+func (m *mockInitRunnable) Stop() <-chan bool {
+	c := make(chan bool, 1)
+	c <- true
+	return c
+}
+
+func newTestService(opts ...Option) *service {
+	s := &service{
+		initServices: map[string]PrefixRunnable{},
+		initDeps:     map[string][]string{},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func prefixesOf(rs []PrefixRunnable) []string {
+	result := make([]string, len(rs))
+	for i := range rs {
+		result[i] = rs[i].GetPrefix()
+	}
+	return result
+}
+
+func TestSortInitServices(t *testing.T) {
+	s := newTestService(
+		WithInitRunnable(&mockInitRunnable{prefix: "migration", deps: []string{"gorm"}}),
+		WithInitRunnable(&mockInitRunnable{prefix: "cache-warmer"}, "redis", "migration"),
+		WithInitRunnable(&mockInitRunnable{prefix: "gorm"}),
+		WithInitRunnable(&mockInitRunnable{prefix: "redis"}),
+	)
+
+	sorted, err := s.sortInitServices()
+	assert.Nil(t, err, "must be nil")
+	assert.Equal(t, []string{"gorm", "migration", "redis", "cache-warmer"}, prefixesOf(sorted), "should be equal")
+}
+
+func TestSortInitServicesCycle(t *testing.T) {
+	s := newTestService(
+		WithInitRunnable(&mockInitRunnable{prefix: "a", deps: []string{"b"}}),
+		WithInitRunnable(&mockInitRunnable{prefix: "b", deps: []string{"c"}}),
+		WithInitRunnable(&mockInitRunnable{prefix: "c", deps: []string{"a"}}),
+	)
+
+	_, err := s.sortInitServices()
+	assert.EqualError(t, err, "init components have a dependency cycle: a -> b -> c -> a")
+}
+
+func TestSortInitServicesUnknownPrefix(t *testing.T) {
+	s := newTestService(
+		WithInitRunnable(&mockInitRunnable{prefix: "migration"}, "gorm"),
+	)
+
+	_, err := s.sortInitServices()
+	assert.EqualError(t, err, "init component migration depends on unknown prefix gorm")
+}
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/200Lab-Education/go-sdk/util/secret"
 	"github.com/facebookgo/flagenv"
 )
 
@@ -66,7 +67,7 @@ func (f *AppFlagSet) GetSampleEnvs() {
 		s := fmt.Sprintf("## %s (-%s)\n", f.Usage, f.Name)
 		s += fmt.Sprintf("#%s=", getEnvName(f.Name))
 
-		if !isZeroValue(f, f.DefValue) {
+		if !isZeroValue(f, f.DefValue) && !secret.IsSensitive(f) {
 			t := fmt.Sprintf("%T", f.Value)
 			if t == "*flag.stringValue" {
 				// put quotes on the value
@@ -79,9 +80,124 @@ func (f *AppFlagSet) GetSampleEnvs() {
 	})
 }
 
-func (f *AppFlagSet) Parse(args []string) {
-	flagenv.Parse()
-	_ = f.FlagSet.Parse(args)
+// Parse sets flags from config file, env and command line args.
+// Precedence is: config file < env < command line.
+// Values of sensitive flags referencing secrets (secret+file://..., ${VAR}) are resolved
+func (f *AppFlagSet) Parse(args []string) error {
+	if err := f.parseEnv(); err != nil {
+		return err
+	}
+
+	if err := f.FlagSet.Parse(args); err != nil {
+		return err
+	}
+
+	var err error
+	f.Visit(func(fl *flag.Flag) {
+		if err == nil {
+			if v := flagValue(fl); secret.IsSensitive(fl) && secret.NeedsResolve(v) {
+				err = setFlag(fl, v)
+			}
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if fl := f.Lookup(configFlagName); fl != nil && fl.Value.String() != "" {
+		return f.applyConfigFile(fl.Value.String())
+	}
+
+	return nil
+}
+
+// knownArgs returns the leading args which are flags of the set (and their values).
+// It stops at the first non-flag arg, "--" or unknown flag, so args of the app
+// (ex: subcommands, flags of go test) do not make Parse fail
+func (f *AppFlagSet) knownArgs(args []string) []string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) < 2 || arg[0] != '-' || arg == "--" {
+			return args[:i]
+		}
+
+		name := strings.TrimPrefix(arg[1:], "-")
+		hasValue := strings.Contains(name, "=")
+		if hasValue {
+			name = name[:strings.Index(name, "=")]
+		}
+
+		fl := f.Lookup(name)
+		if fl == nil {
+			return args[:i]
+		}
+
+		if bf, ok := fl.Value.(interface{ IsBoolFlag() bool }); ok && bf.IsBoolFlag() {
+			continue
+		}
+
+		if !hasValue {
+			// value is the next arg
+			i++
+		}
+	}
+
+	return args
+}
+
+// parseEnv sets flags which are not set by command line from env
+func (f *AppFlagSet) parseEnv() error {
+	explicit := map[string]bool{}
+	f.Visit(func(fl *flag.Flag) { explicit[fl.Name] = true })
+
+	var err error
+	f.VisitAll(func(fl *flag.Flag) {
+		if err != nil || explicit[fl.Name] {
+			return
+		}
+
+		if v := os.Getenv(getEnvName(fl.Name)); v != "" {
+			err = setFlag(fl, v)
+		}
+	})
+
+	return err
+}
+
+// setFlag resolves secret reference of a sensitive flag value then sets it to the flag
+func setFlag(f *flag.Flag, value string) error {
+	if secret.IsSensitive(f) && secret.NeedsResolve(value) {
+		v, err := secret.Resolve(value)
+		if err != nil {
+			return fmt.Errorf("failed to set flag %q: %s", f.Name, err.Error())
+		}
+		value = v
+	}
+
+	if err := f.Value.Set(value); err != nil {
+		if secret.IsSensitive(f) {
+			value = secret.Masked
+		}
+		return fmt.Errorf("failed to set flag %q with value %q", f.Name, value)
+	}
+
+	return nil
+}
+
+// flagValue returns the real value of a flag, secret flags are masked by String()
+func flagValue(f *flag.Flag) string {
+	if g, ok := f.Value.(flag.Getter); ok {
+		if s, ok := g.Get().(string); ok {
+			return s
+		}
+	}
+
+	return f.Value.String()
+}
+
+func isEnvSet(name string) bool {
+	return os.Getenv(getEnvName(name)) != ""
 }
 
 // inspect from PrintDefaults
@@ -105,7 +221,11 @@ func flagCustomUsage(appname string, fSet *AppFlagSet) func() {
 				s += "\n    \t"
 			}
 			s += usage
-			if !isZeroValue(f, f.DefValue) {
+			if secret.IsSensitive(f) {
+				if f.DefValue != "" {
+					s += fmt.Sprintf(" (default %s)", secret.Masked)
+				}
+			} else if !isZeroValue(f, f.DefValue) {
 				t := fmt.Sprintf("%T", f.Value)
 				if t == "*flag.stringValue" {
 					// put quotes on the value
@@ -0,0 +1,117 @@
+package goservice
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/util/secret"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKnownArgs(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("gin-port", "", "")
+	fs.Bool("validate", false, "")
+	f := newFlagSet("test", fs)
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{}, []string{}},
+		{[]string{"-gin-port", "3000", "migrate", "-force"}, []string{"-gin-port", "3000"}},
+		{[]string{"--gin-port=3000", "-validate", "-test.v"}, []string{"--gin-port=3000", "-validate"}},
+		{[]string{"-validate", "--", "-gin-port", "1"}, []string{"-validate"}},
+		{[]string{"-unknown", "-gin-port", "1"}, []string{}},
+		{[]string{"migrate"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, f.knownArgs(tt.args), "args %v", tt.args)
+	}
+
+	assert.Nil(t, f.Parse(f.knownArgs([]string{"-gin-port", "3000", "-test.run", "X"})), "unknown args must not fail")
+	assert.Equal(t, "3000", fs.Lookup("gin-port").Value.String(), "should be equal")
+}
+
+func TestFlattenConfig(t *testing.T) {
+	values := map[string]string{}
+	flattenConfig("", map[string]interface{}{
+		"gin": map[string]interface{}{
+			"port":         3000,
+			"cors_origins": []interface{}{"a.com", "b.com"},
+		},
+		"gorm-db-uri": "mysql://",
+		"empty":       nil,
+	}, values)
+
+	assert.Equal(t, map[string]string{
+		"gin-port":         "3000",
+		"gin-cors-origins": "a.com,b.com",
+		"gorm-db-uri":      "mysql://",
+		"empty":            "",
+	}, values, "should be equal")
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	assert.Nil(t, ioutil.WriteFile(configFile, []byte("precedence:\n  file: file\n  env: file\n  cli: file\n"), 0644), "must be nil")
+
+	assert.Nil(t, os.Setenv("PRECEDENCE_ENV", "env"), "must be nil")
+	assert.Nil(t, os.Setenv("PRECEDENCE_CLI", "env"), "must be nil")
+	t.Cleanup(func() {
+		_ = os.Unsetenv("PRECEDENCE_ENV")
+		_ = os.Unsetenv("PRECEDENCE_CLI")
+	})
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String(configFlagName, "", "")
+	for _, name := range []string{"precedence-default", "precedence-file", "precedence-env", "precedence-cli"} {
+		fs.String(name, "default", "")
+	}
+
+	f := newFlagSet("test", fs)
+	assert.Nil(t, f.Parse([]string{"-config", configFile, "-precedence-cli", "cli"}), "must be nil")
+
+	tests := []struct {
+		flag string
+		want string
+	}{
+		{"precedence-default", "default"},
+		{"precedence-file", "file"},
+		{"precedence-env", "env"},
+		{"precedence-cli", "cli"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, fs.Lookup(tt.flag).Value.String(), "flag %s", tt.flag)
+	}
+}
+
+func TestResolveOnlySensitiveFlags(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "password")
+	assert.Nil(t, ioutil.WriteFile(secretFile, []byte("s3cret\n"), 0600), "must be nil")
+
+	assert.Nil(t, os.Setenv("RESOLVE_TEST_HOST", "db.local"), "must be nil")
+	t.Cleanup(func() { _ = os.Unsetenv("RESOLVE_TEST_HOST") })
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	dbURI := fs.String("resolve-db-uri", "", "")
+	password := fs.String("resolve-password", "", "")
+	template := fs.String("resolve-template", "", "")
+	secret.Mark("resolve-db-uri", "resolve-password")
+
+	f := newFlagSet("test", fs)
+	assert.Nil(t, f.Parse([]string{
+		"-resolve-db-uri", "file:///data/app.db",
+		"-resolve-password", "secret+file://" + secretFile,
+		"-resolve-template", "https://${RESOLVE_TEST_HOST}/",
+	}), "must be nil")
+
+	assert.Equal(t, "file:///data/app.db", *dbURI, "plain file URI must be kept")
+	assert.Equal(t, "s3cret", *password, "secret reference must be resolved")
+	assert.Equal(t, "https://${RESOLVE_TEST_HOST}/", *template, "non-sensitive flag must not be resolved")
+}
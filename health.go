@@ -0,0 +1,169 @@
+// Copyright (c) 2019, Viet Tran, 200Lab Team.
+
+package goservice
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HealthStatusUp       = "up"
+	HealthStatusDown     = "down"
+	HealthStatusDegraded = "degraded"
+	HealthStatusStopping = "stopping"
+)
+
+// Health of a single component, it is refreshed in background
+type ComponentHealth struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Aggregated health of the service, returned by /healthz and /readyz
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+type healthMonitor struct {
+	mu         sync.RWMutex
+	checkers   map[string]HealthChecker
+	results    map[string]ComponentHealth
+	isStopping bool
+	stopChan   chan bool
+	enabled    bool
+	interval   int // in seconds
+}
+
+func newHealthMonitor() *healthMonitor {
+	return &healthMonitor{
+		checkers: map[string]HealthChecker{},
+		results:  map[string]ComponentHealth{},
+		stopChan: make(chan bool),
+	}
+}
+
+func (hm *healthMonitor) add(r Runnable) {
+	if hc, ok := r.(HealthChecker); ok {
+		hm.checkers[r.Name()] = hc
+	}
+}
+
+// run checks all components every interval until stop is called
+func (hm *healthMonitor) run() {
+	interval := time.Second * time.Duration(hm.interval)
+	if interval <= 0 {
+		interval = time.Second * 5
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		hm.checkAll(interval)
+
+		select {
+		case <-hm.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (hm *healthMonitor) checkAll(timeout time.Duration) {
+	wg := new(sync.WaitGroup)
+
+	for name, checker := range hm.checkers {
+		wg.Add(1)
+
+		go func(name string, checker HealthChecker) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			result := ComponentHealth{Status: HealthStatusUp, CheckedAt: time.Now().UTC()}
+			if err := checker.HealthCheck(ctx); err != nil {
+				result.Status = HealthStatusDown
+				result.Error = err.Error()
+			}
+
+			hm.mu.Lock()
+			hm.results[name] = result
+			hm.mu.Unlock()
+		}(name, checker)
+	}
+
+	wg.Wait()
+}
+
+func (hm *healthMonitor) stop() {
+	hm.mu.Lock()
+	wasStopping := hm.isStopping
+	hm.isStopping = true
+	hm.mu.Unlock()
+
+	if !wasStopping {
+		close(hm.stopChan)
+	}
+}
+
+// report returns latest results and whether all components are up
+func (hm *healthMonitor) report() (HealthReport, bool) {
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+
+	report := HealthReport{
+		Status:     HealthStatusUp,
+		Components: make(map[string]ComponentHealth, len(hm.checkers)),
+	}
+
+	for name := range hm.checkers {
+		result, ok := hm.results[name]
+		if !ok {
+			result = ComponentHealth{Status: HealthStatusDown, Error: "not checked yet"}
+		}
+
+		if result.Status != HealthStatusUp {
+			report.Status = HealthStatusDegraded
+		}
+
+		report.Components[name] = result
+	}
+
+	if hm.isStopping {
+		report.Status = HealthStatusStopping
+	}
+
+	return report, report.Status == HealthStatusUp
+}
+
+// Liveness probe: the service answers as long as the process is serving,
+// components being down must not get the pod restarted
+func (hm *healthMonitor) livenessHandler(c *gin.Context) {
+	report, _ := hm.report()
+	c.JSON(http.StatusOK, report)
+}
+
+// Readiness probe: fails when any component is down or the service is stopping
+func (hm *healthMonitor) readinessHandler(c *gin.Context) {
+	report, ready := hm.report()
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (hm *healthMonitor) registerRoutes(engine *gin.Engine) {
+	engine.GET("/healthz", hm.livenessHandler)
+	engine.GET("/readyz", hm.readinessHandler)
+}
@@ -0,0 +1,102 @@
+package goservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockChecker struct {
+	mockRunnable
+	err error
+}
+
+func (m *mockChecker) HealthCheck(ctx context.Context) error { return m.err }
+
+func serveHealth(t *testing.T, hm *healthMonitor, path string) (int, HealthReport) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	hm.registerRoutes(engine)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var report HealthReport
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &report), "must be nil")
+	return w.Code, report
+}
+
+func TestHealthReport(t *testing.T) {
+	db := &mockChecker{mockRunnable: mockRunnable{name: "db"}}
+	cache := &mockChecker{mockRunnable: mockRunnable{name: "cache"}}
+
+	hm := newHealthMonitor()
+	hm.add(db)
+	hm.add(cache)
+	hm.add(&mockRunnable{name: "worker"})
+
+	report, ready := hm.report()
+	assert.False(t, ready, "unchecked components must not be ready")
+	assert.Equal(t, HealthStatusDegraded, report.Status, "should be equal")
+
+	hm.checkAll(time.Second)
+
+	code, report := serveHealth(t, hm, "/readyz")
+	assert.Equal(t, http.StatusOK, code, "should be equal")
+	assert.Equal(t, HealthStatusUp, report.Status, "should be equal")
+	assert.Len(t, report.Components, 2, "only health checkers are reported")
+
+	cache.err = errors.New("connection refused")
+	hm.checkAll(time.Second)
+
+	code, report = serveHealth(t, hm, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code, "should be equal")
+	assert.Equal(t, HealthStatusDegraded, report.Status, "should be equal")
+	assert.Equal(t, HealthStatusUp, report.Components["db"].Status, "should be equal")
+	assert.Equal(t, HealthStatusDown, report.Components["cache"].Status, "should be equal")
+	assert.Equal(t, "connection refused", report.Components["cache"].Error, "should be equal")
+
+	code, report = serveHealth(t, hm, "/healthz")
+	assert.Equal(t, http.StatusOK, code, "liveness must not fail on a component")
+	assert.Equal(t, HealthStatusDegraded, report.Status, "should be equal")
+
+	cache.err = nil
+	hm.checkAll(time.Second)
+	hm.stop()
+	hm.stop()
+
+	code, report = serveHealth(t, hm, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code, "stopping service must not be ready")
+	assert.Equal(t, HealthStatusStopping, report.Status, "should be equal")
+}
+
+func TestHealthMonitorRun(t *testing.T) {
+	hm := newHealthMonitor()
+	hm.add(&mockChecker{mockRunnable: mockRunnable{name: "db"}})
+
+	done := make(chan struct{})
+	go func() {
+		hm.run()
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, ready := hm.report()
+		return ready
+	}, time.Second, 10*time.Millisecond, "first check runs immediately")
+
+	hm.stop()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("run must return after stop")
+	}
+}
@@ -6,12 +6,17 @@ import (
 	"fmt"
 	"github.com/200Lab-Education/go-sdk/httpserver/middleware"
 	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/util/metrics"
 	"github.com/gin-gonic/gin"
 	"go.opencensus.io/plugin/ochttp"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"net"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
+	"time"
 )
 
 var (
@@ -20,10 +25,44 @@ var (
 	defaultPort = 3000
 )
 
+const unixScheme = "unix://"
+
 type Config struct {
 	Port         int    `json:"http_port"`
 	BindAddr     string `json:"http_bind_addr"`
 	GinNoDefault bool   `json:"http_no_default"`
+	TLS          TLSConfig
+	Limits       LimitConfig
+}
+
+type LimitConfig struct {
+	ReadTimeout       time.Duration `json:"http_read_timeout"`
+	ReadHeaderTimeout time.Duration `json:"http_read_header_timeout"`
+	WriteTimeout      time.Duration `json:"http_write_timeout"`
+	IdleTimeout       time.Duration `json:"http_idle_timeout"`
+	KeepAlivePeriod   time.Duration `json:"http_keep_alive_period"`
+	MaxHeaderBytes    int           `json:"http_max_header_bytes"`
+	MaxBodyBytes      int64         `json:"http_max_body_bytes"`
+	MaxConnections    int           `json:"http_max_connections"`
+}
+
+func (c LimitConfig) check() error {
+	for name, v := range map[string]int64{
+		"read-timeout":        int64(c.ReadTimeout),
+		"read-header-timeout": int64(c.ReadHeaderTimeout),
+		"write-timeout":       int64(c.WriteTimeout),
+		"idle-timeout":        int64(c.IdleTimeout),
+		"keep-alive-period":   int64(c.KeepAlivePeriod),
+		"max-header-bytes":    int64(c.MaxHeaderBytes),
+		"max-body-bytes":      c.MaxBodyBytes,
+		"max-connections":     int64(c.MaxConnections),
+	} {
+		if v < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+
+	return nil
 }
 
 type GinService interface {
@@ -36,37 +75,240 @@ type ginService struct {
 	Config
 	isEnabled bool
 	name      string
-	logger    logger.Logger
-	svr       *myHttpServer
-	router    *gin.Engine
-	mu        *sync.Mutex
-	handlers  []func(*gin.Engine)
+	// empty for the default server
+	prefix   string
+	noLogger bool
+	h2c      bool
+	// pre-made listener, ex: systemd socket activation or tests
+	listener        net.Listener
+	cors            corsFlags
+	accessLog       string
+	accessLogConfig accessLogFlags
+	metrics         bool
+	// response compression and ETag of the default middleware stack
+	compress        bool
+	compressMinSize int
+	etag            bool
+	// format of error responses, log is only shown in dev env
+	errorRender middleware.ErrorRenderConfig
+	logger      logger.Logger
+	svr         *myHttpServer
+	router      *gin.Engine
+	mu          *sync.Mutex
+	// closed when the server is listening, renewed when Run returns
+	started  chan struct{}
+	handlers []func(*gin.Engine)
+	// receive panics caught by the default middleware stack
+	reporters []middleware.ErrorReporter
+	// cancel waiting in-flight requests on shutdown
+	cancelShutdown context.CancelFunc
 	//registeredID  string
 	//registryAgent registry.Agent
 }
 
+type accessLogFlags struct {
+	fields     string
+	sampleRate float64
+	skipPaths  string
+}
+
+func (f accessLogFlags) config() AccessLogConfig {
+	cfg := DefaultAccessLogConfig()
+	cfg.Fields = splitList(f.fields)
+	cfg.SampleRate = f.sampleRate
+
+	if f.skipPaths != "" {
+		cfg.SkipPaths = splitList(f.skipPaths)
+	}
+
+	return cfg
+}
+
+// CORS flags, lists are comma separated
+type corsFlags struct {
+	origins       string
+	methods       string
+	headers       string
+	exposeHeaders string
+	maxAge        time.Duration
+	credentials   bool
+}
+
+func (f corsFlags) config() middleware.CORSConfig {
+	cfg := middleware.DefaultCORSConfig()
+	cfg.AllowOrigins = splitList(f.origins)
+	cfg.ExposeHeaders = splitList(f.exposeHeaders)
+	cfg.MaxAge = f.maxAge
+	cfg.AllowCredentials = f.credentials
+
+	if f.methods != "" {
+		cfg.AllowMethods = splitList(f.methods)
+	}
+
+	if f.headers != "" {
+		cfg.AllowHeaders = splitList(f.headers)
+	}
+
+	return cfg
+}
+
+func splitList(s string) []string {
+	var result []string
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
 func New(name string) *ginService {
+	return NewWithPrefix(name, "")
+}
+
+// NewWithPrefix creates another gin server in the same service,
+// its flags are prefixed. Ex: prefix "internal" => internal-port, internal-addr
+func NewWithPrefix(name, prefix string) *ginService {
 	return &ginService{
 		name:     name,
+		prefix:   strings.TrimSpace(prefix),
 		mu:       &sync.Mutex{},
+		started:  make(chan struct{}),
 		handlers: []func(*gin.Engine){},
 	}
 }
 
 func (gs *ginService) Name() string {
-	return gs.name + "-gin"
+	if gs.prefix == "" {
+		return gs.name + "-gin"
+	}
+
+	return gs.name + "-" + gs.prefix + "-gin"
+}
+
+func (gs *ginService) GetPrefix() string {
+	return gs.prefix
+}
+
+// flagName returns name of a server flag: gin-<name> for the default server,
+// <prefix>-<name> for the others
+func (gs *ginService) flagName(name string) string {
+	if gs.prefix == "" {
+		return "gin-" + name
+	}
+
+	return gs.prefix + "-" + name
 }
 
 func (gs *ginService) InitFlags() {
-	prefix := "gin"
-	flag.IntVar(&gs.Config.Port, prefix+"Port", defaultPort, "gin server Port. If 0 => get a random Port")
-	flag.StringVar(&gs.BindAddr, prefix+"addr", "", "gin server bind address")
-	flag.StringVar(&ginMode, "gin-mode", "", "gin mode")
-	flag.BoolVar(&ginNoLogger, "gin-no-logger", false, "disable default gin logger middleware")
+	if gs.prefix == "" {
+		prefix := "gin"
+		flag.IntVar(&gs.Config.Port, prefix+"Port", defaultPort, "gin server Port. If 0 => get a random Port")
+		flag.StringVar(&gs.BindAddr, prefix+"addr", "", "gin server bind address")
+		flag.StringVar(&ginMode, "gin-mode", "", "gin mode")
+		flag.BoolVar(&ginNoLogger, "gin-no-logger", false, "disable default gin logger middleware")
+	} else {
+		flag.IntVar(&gs.Config.Port, gs.flagName("port"), 0, fmt.Sprintf("%s gin server Port. If 0 => get a random Port", gs.prefix))
+		flag.StringVar(&gs.BindAddr, gs.flagName("addr"), "", fmt.Sprintf("%s gin server bind address", gs.prefix))
+		flag.BoolVar(&gs.noLogger, gs.flagName("no-logger"), false, fmt.Sprintf("disable default gin logger middleware of %s server", gs.prefix))
+	}
+
+	flag.DurationVar(&gs.Limits.ReadTimeout, gs.flagName("read-timeout"), 0, "Max duration for reading the entire request including body, 0 means no limit")
+	flag.DurationVar(&gs.Limits.ReadHeaderTimeout, gs.flagName("read-header-timeout"), 10*time.Second, "Max duration for reading request headers, protects from slow clients")
+	flag.DurationVar(&gs.Limits.WriteTimeout, gs.flagName("write-timeout"), 0, "Max duration before timing out writes of the response, 0 means no limit")
+	flag.DurationVar(&gs.Limits.IdleTimeout, gs.flagName("idle-timeout"), 2*time.Minute, "Max duration to wait for the next request on keep-alive connections")
+	flag.DurationVar(&gs.Limits.KeepAlivePeriod, gs.flagName("keep-alive-period"), defaultKeepAlivePeriod, "TCP keep-alive period of accepted connections, 0 disables it")
+	flag.IntVar(&gs.Limits.MaxHeaderBytes, gs.flagName("max-header-bytes"), http.DefaultMaxHeaderBytes, "Max size of request headers")
+	flag.Int64Var(&gs.Limits.MaxBodyBytes, gs.flagName("max-body-bytes"), 0, "Max size of request body, larger requests get 413. 0 means no limit")
+	flag.IntVar(&gs.Limits.MaxConnections, gs.flagName("max-connections"), 0, "Max concurrent connections, 0 means no limit")
+	flag.StringVar(&gs.cors.origins, gs.flagName("cors-origins"), "", "Allowed CORS origins, comma separated. Ex: https://example.com,https://*.example.com,regex:^https://.+\\.dev$. CORS is disabled if empty")
+	flag.StringVar(&gs.cors.methods, gs.flagName("cors-methods"), "", "Allowed CORS methods, comma separated. Default GET,POST,PUT,PATCH,DELETE,HEAD,OPTIONS")
+	flag.StringVar(&gs.cors.headers, gs.flagName("cors-headers"), "", "Allowed CORS request headers, comma separated. Default common headers and Authorization")
+	flag.StringVar(&gs.cors.exposeHeaders, gs.flagName("cors-expose-headers"), "", "Response headers exposed to browsers, comma separated")
+	flag.DurationVar(&gs.cors.maxAge, gs.flagName("cors-max-age"), 12*time.Hour, "How long browsers cache preflight responses")
+	flag.BoolVar(&gs.cors.credentials, gs.flagName("cors-credentials"), false, "Allow cookies and Authorization with CORS requests")
+	flag.StringVar(&gs.accessLog, gs.flagName("access-log"), AccessLogGin, "Access log of the default middleware stack: gin (plain text) | sdk (structured, SDK logger) | none")
+	flag.StringVar(&gs.accessLogConfig.fields, gs.flagName("access-log-fields"), "", "Fields of sdk access log, comma separated. Default: "+strings.Join(defaultAccessLogFields, ",")+". Optional: "+strings.Join(optionalAccessLogFields, ","))
+	flag.Float64Var(&gs.accessLogConfig.sampleRate, gs.flagName("access-log-sample"), 1, "Ratio (0..1) of successful requests written to sdk access log, errors are always written")
+	flag.StringVar(&gs.accessLogConfig.skipPaths, gs.flagName("access-log-skip"), "/healthz,/readyz", "Paths or routes not written to sdk access log when successful, comma separated")
+	flag.BoolVar(&gs.metrics, gs.flagName("metrics"), true, "Record HTTP request count and latency metrics (default middleware stack)")
+	flag.BoolVar(&gs.compress, gs.flagName("compress"), true, "Compress responses with gzip/deflate (default middleware stack)")
+	flag.IntVar(&gs.compressMinSize, gs.flagName("compress-min-size"), 1024, "Responses smaller than this (in bytes) are not compressed")
+	flag.BoolVar(&gs.etag, gs.flagName("etag"), false, "Add ETag to GET responses and answer If-None-Match with 304 (default middleware stack). Responses are buffered to be hashed")
+	flag.StringVar(&gs.errorRender.Format, gs.flagName("error-format"), middleware.ErrorFormatJSON, "Format of error responses: json (AppError) | problem (RFC 7807 application/problem+json)")
+	flag.StringVar(&gs.errorRender.TypeBaseURI, gs.flagName("problem-type-uri"), "", "Base URI of problem types, type is <uri>/<error code>. Default about:blank")
+	flag.BoolVar(&gs.h2c, gs.flagName("h2c"), false, "Serve HTTP/2 without TLS (h2c), ex: behind a sidecar proxy. Ignored when TLS is enabled")
+	flag.StringVar(&gs.TLS.CertFile, gs.flagName("tls-cert"), "", "TLS certificate file, server uses HTTPS when it is set. Reloaded when changed")
+	flag.StringVar(&gs.TLS.KeyFile, gs.flagName("tls-key"), "", "TLS private key file")
+	flag.StringVar(&gs.TLS.ClientCAFile, gs.flagName("tls-client-ca"), "", "CA file to verify client certificates (mTLS)")
+	flag.StringVar(&gs.TLS.ClientAuth, gs.flagName("tls-client-auth"), "", "Client certificate policy: none | request | verify-if-given | require. Default require when client CA is set")
+}
+
+// Implement Validator interface
+func (gs *ginService) Validate() error {
+	if gs.Config.Port < 0 || gs.Config.Port > 65535 {
+		return fmt.Errorf("%s port %d is out of range", gs.loggerName(), gs.Config.Port)
+	}
+
+	if path, ok := unixSocketPath(gs.BindAddr); ok && path == "" {
+		return fmt.Errorf("%s: unix socket path is empty", gs.loggerName())
+	}
+
+	if err := gs.Limits.check(); err != nil {
+		return fmt.Errorf("%s: %s", gs.loggerName(), err.Error())
+	}
+
+	switch gs.accessLog {
+	case AccessLogGin, AccessLogSDK, AccessLogNone:
+	default:
+		return fmt.Errorf("%s: access log %q is not supported, use: gin | sdk | none", gs.loggerName(), gs.accessLog)
+	}
+
+	if err := gs.accessLogConfig.config().Validate(); err != nil {
+		return fmt.Errorf("%s: %s", gs.loggerName(), err.Error())
+	}
+
+	if err := gs.errorRender.Validate(); err != nil {
+		return fmt.Errorf("%s: %s", gs.loggerName(), err.Error())
+	}
+
+	if err := gs.cors.config().Validate(); err != nil {
+		return fmt.Errorf("%s: %s", gs.loggerName(), err.Error())
+	}
+
+	if err := gs.TLS.check(); err != nil {
+		return fmt.Errorf("%s: %s", gs.loggerName(), err.Error())
+	}
+
+	switch ginMode {
+	case "", gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+	default:
+		return fmt.Errorf("gin mode %q is not supported, use: debug | release | test", ginMode)
+	}
+
+	return nil
+}
+
+func (gs *ginService) metricsServerLabel() string {
+	if gs.prefix == "" {
+		return "default"
+	}
+
+	return gs.prefix
+}
+
+func (gs *ginService) loggerName() string {
+	if gs.prefix == "" {
+		return "gin"
+	}
+
+	return gs.prefix + "-gin"
 }
 
 func (gs *ginService) Configure() error {
-	gs.logger = logger.GetCurrent().GetLogger("gin")
+	gs.logger = logger.GetCurrent().GetLogger(gs.loggerName())
 
 	if ginMode == "release" {
 		gin.SetMode(gin.ReleaseMode)
@@ -74,20 +316,75 @@ func (gs *ginService) Configure() error {
 
 	gs.logger.Debug("init gin engine...")
 	gs.router = gin.New()
+	gs.router.Use(middleware.RequestID())
+
+	// first so error responses of the other middlewares (413, 429, 500) have CORS headers
+	if gs.cors.origins != "" {
+		gs.router.Use(middleware.CORS(gs.cors.config()))
+	}
+
+	gs.router.Use(
+		middleware.ErrorRender(gs.errorRender),
+		middleware.BodyLimit(gs.Limits.MaxBodyBytes),
+	)
+
 	if !gs.GinNoDefault {
-		if !ginNoLogger {
-			gs.router.Use(gin.Logger())
+		if gs.metrics {
+			gs.router.Use(middleware.Metrics(metrics.Default(), gs.metricsServerLabel()))
+		}
+
+		if !ginNoLogger && !gs.noLogger {
+			switch gs.accessLog {
+			case AccessLogGin:
+				gs.router.Use(gin.Logger())
+			case AccessLogSDK:
+				gs.router.Use(AccessLogger(logger.GetCurrent().GetLogger(gs.loggerName()+"-access"), gs.accessLogConfig.config()))
+			}
 		}
 		//gs.router.Use(gin.Recovery())
-		gs.router.Use(middleware.PanicLogger())
+		gs.router.Use(middleware.Recovery(gs.logger, gs.reporters...))
+
+		if gs.compress {
+			cfg := middleware.DefaultCompressConfig()
+			cfg.MinSize = gs.compressMinSize
+			gs.router.Use(middleware.Compress(cfg))
+		}
+
+		if gs.etag {
+			gs.router.Use(middleware.ETag())
+		}
 	}
 
-	och := &ochttp.Handler{
+	var handler http.Handler = &ochttp.Handler{
 		Handler: gs.router,
 	}
 
+	if gs.h2c && !gs.TLS.IsEnabled() {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+
 	gs.svr = &myHttpServer{
-		Server: http.Server{Handler: och},
+		Server: http.Server{
+			Handler:           handler,
+			ReadTimeout:       gs.Limits.ReadTimeout,
+			ReadHeaderTimeout: gs.Limits.ReadHeaderTimeout,
+			WriteTimeout:      gs.Limits.WriteTimeout,
+			IdleTimeout:       gs.Limits.IdleTimeout,
+			MaxHeaderBytes:    gs.Limits.MaxHeaderBytes,
+		},
+		keepAlivePeriod: gs.Limits.KeepAlivePeriod,
+		maxConnections:  gs.Limits.MaxConnections,
+	}
+
+	if gs.TLS.IsEnabled() {
+		reloader, err := newCertReloader(gs.TLS, func(err error) {
+			gs.logger.Errorf("cannot reload tls cert, keep using the old one: %s", err.Error())
+		})
+		if err != nil {
+			return err
+		}
+
+		gs.svr.TLSConfig = reloader.tlsConfig()
 	}
 
 	return nil
@@ -113,18 +410,30 @@ func (gs *ginService) Run() error {
 		hdl(gs.router)
 	}
 
-	addr := formatBindAddr(gs.BindAddr, gs.Config.Port)
-	gs.logger.Debugf("start listen tcp %s...", addr)
-	lis, err := net.Listen("tcp", addr)
+	lis, err := gs.listen()
 	if err != nil {
 		gs.logger.Fatalf("failed to listen: %v", err)
 	}
 
-	gs.Config.Port = getPort(lis)
-
-	gs.logger.Infof("listen on %s...", lis.Addr().String())
+	gs.mu.Lock()
+	gs.Config.Port = getPort(lis, gs.Config.Port)
+	close(gs.started)
+	gs.mu.Unlock()
+
+	defer func() {
+		gs.mu.Lock()
+		gs.started = make(chan struct{})
+		gs.mu.Unlock()
+	}()
 
-	err = gs.svr.Serve(lis)
+	if gs.TLS.IsEnabled() {
+		gs.logger.Infof("listen on %s (tls)...", lis.Addr().String())
+		// certificates are served by TLSConfig
+		err = gs.svr.ServeTLS(lis, "", "")
+	} else {
+		gs.logger.Infof("listen on %s...", lis.Addr().String())
+		err = gs.svr.Serve(lis)
+	}
 
 	if err != nil && err == http.ErrServerClosed {
 		return nil
@@ -132,10 +441,76 @@ func (gs *ginService) Run() error {
 	return err
 }
 
-func getPort(lis net.Listener) int {
-	addr := lis.Addr()
-	tcp, _ := net.ResolveTCPAddr(addr.Network(), addr.String())
-	return tcp.Port
+// listen uses the injected listener if any, bind address
+// can be a unix socket: unix:///path/to/sock
+func (gs *ginService) listen() (net.Listener, error) {
+	if gs.listener != nil {
+		return gs.listener, nil
+	}
+
+	if path, ok := unixSocketPath(gs.BindAddr); ok {
+		gs.logger.Debugf("start listen unix %s...", path)
+
+		if err := removeStaleSocket(path); err != nil {
+			return nil, err
+		}
+
+		return net.Listen("unix", path)
+	}
+
+	addr := formatBindAddr(gs.BindAddr, gs.Config.Port)
+	gs.logger.Debugf("start listen tcp %s...", addr)
+	return net.Listen("tcp", addr)
+}
+
+// removeStaleSocket removes a socket file left by a previous process,
+// any other file at the path is kept and reported
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a unix socket", path)
+	}
+
+	return os.Remove(path)
+}
+
+func unixSocketPath(addr string) (string, bool) {
+	if strings.HasPrefix(addr, unixScheme) {
+		return strings.TrimPrefix(addr, unixScheme), true
+	}
+
+	return "", false
+}
+
+// getPort returns current port when listener is not TCP
+func getPort(lis net.Listener, current int) int {
+	if tcp, ok := lis.Addr().(*net.TCPAddr); ok {
+		return tcp.Port
+	}
+
+	return current
+}
+
+// SetListener makes server serve on a pre-made listener instead of
+// its bind address, it must be called before Run
+func (gs *ginService) SetListener(lis net.Listener) {
+	gs.listener = lis
+}
+
+// Implement StartNotifier, Run of a disabled server returns without starting
+func (gs *ginService) Started() <-chan struct{} {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	return gs.started
 }
 
 func (gs *ginService) Port() int {
@@ -145,18 +520,48 @@ func (gs *ginService) Port() int {
 }
 
 func (gs *ginService) Stop() <-chan bool {
-	c := make(chan bool)
+	c := make(chan bool, 1)
 
 	go func() {
 		if gs.svr != nil {
-			_ = gs.svr.Shutdown(context.Background())
+			ctx, cancel := context.WithCancel(context.Background())
+
+			gs.mu.Lock()
+			gs.cancelShutdown = cancel
+			gs.mu.Unlock()
+
+			_ = gs.svr.Shutdown(ctx)
+			cancel()
 		}
 		c <- true
 	}()
 	return c
 }
 
+// Implement ForceStopper, drop in-flight requests and close all connections
+func (gs *ginService) ForceStop() error {
+	if gs.svr == nil {
+		return nil
+	}
+
+	gs.mu.Lock()
+	if gs.cancelShutdown != nil {
+		gs.cancelShutdown()
+	}
+	gs.mu.Unlock()
+
+	return gs.svr.Close()
+}
+
 func (gs *ginService) URI() string {
+	if gs.listener != nil {
+		return gs.listener.Addr().String()
+	}
+
+	if _, ok := unixSocketPath(gs.BindAddr); ok {
+		return gs.BindAddr
+	}
+
 	return formatBindAddr(gs.BindAddr, gs.Config.Port)
 }
 
@@ -165,6 +570,17 @@ func (gs *ginService) AddHandler(hdl func(*gin.Engine)) {
 	gs.handlers = append(gs.handlers, hdl)
 }
 
+// AddErrorReporter sends panics recovered by the default middleware stack
+// to the reporter, it must be called before Run
+func (gs *ginService) AddErrorReporter(r middleware.ErrorReporter) {
+	gs.reporters = append(gs.reporters, r)
+}
+
+// ShowErrorLog renders AppError.Log to clients, it must be called before Run
+func (gs *ginService) ShowErrorLog(show bool) {
+	gs.errorRender.ShowLog = show
+}
+
 func (gs *ginService) Reload(config Config) error {
 	gs.Config = config
 	<-gs.Stop()
@@ -175,6 +591,10 @@ func (gs *ginService) GetConfig() Config {
 	return gs.Config
 }
 
+func (gs *ginService) IsEnabled() bool {
+	return gs.isEnabled
+}
+
 func (gs *ginService) IsRunning() bool {
 	return gs.svr != nil
 }
@@ -0,0 +1,106 @@
+package httpserver
+
+import (
+	"flag"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// withCommandLine registers flags of the test on a new command line
+func withCommandLine(t *testing.T) *flag.FlagSet {
+	cmdLine := flag.CommandLine
+	flag.CommandLine = flag.NewFlagSet("test", flag.ContinueOnError)
+	t.Cleanup(func() { flag.CommandLine = cmdLine })
+
+	return flag.CommandLine
+}
+
+func TestRemoveStaleSocket(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.Nil(t, removeStaleSocket(filepath.Join(dir, "missing.sock")), "must be nil")
+
+	file := filepath.Join(dir, "data.txt")
+	assert.Nil(t, ioutil.WriteFile(file, []byte("keep me"), 0644), "must be nil")
+	assert.NotNil(t, removeStaleSocket(file), "must not be nil")
+	_, err := os.Stat(file)
+	assert.Nil(t, err, "regular file must be kept")
+
+	sock := filepath.Join(dir, "app.sock")
+	lis, err := net.Listen("unix", sock)
+	assert.Nil(t, err, "must be nil")
+	lis.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = lis.Close()
+
+	assert.Nil(t, removeStaleSocket(sock), "must be nil")
+	_, err = os.Stat(sock)
+	assert.True(t, os.IsNotExist(err), "stale socket must be removed")
+}
+
+func TestPrefixedServerFlags(t *testing.T) {
+	fs := withCommandLine(t)
+
+	public := New("demo")
+	internal := NewWithPrefix("demo", "internal")
+	public.InitFlags()
+	internal.InitFlags()
+
+	assert.Equal(t, "demo-gin", public.Name(), "should be equal")
+	assert.Equal(t, "demo-internal-gin", internal.Name(), "should be equal")
+
+	for _, name := range []string{"internal-port", "internal-addr", "internal-no-logger", "internal-cors-origins", "internal-max-body-bytes", "internal-tls-cert"} {
+		assert.NotNil(t, fs.Lookup(name), "flag %s should be registered", name)
+	}
+
+	assert.Nil(t, fs.Parse([]string{"-ginPort", "3000", "-internal-port", "3001", "-internal-max-body-bytes", "10"}), "must be nil")
+	assert.Equal(t, 3000, public.Port(), "should be equal")
+	assert.Equal(t, 3001, internal.Port(), "should be equal")
+	assert.Equal(t, int64(0), public.Limits.MaxBodyBytes, "flags of servers must be independent")
+	assert.Equal(t, int64(10), internal.Limits.MaxBodyBytes, "should be equal")
+}
+
+func TestCORSHeadersOnMiddlewareErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.InitServLogger(false)
+	fs := withCommandLine(t)
+
+	gs := New("demo")
+	gs.InitFlags()
+	assert.Nil(t, fs.Parse([]string{"-gin-cors-origins", "https://example.com", "-gin-max-body-bytes", "4", "-gin-access-log", "none"}), "must be nil")
+	assert.Nil(t, gs.Configure(), "must be nil")
+
+	gs.router.POST("/items", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	gs.router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodPost, "/items", "too large body", http.StatusRequestEntityTooLarge},
+		{http.MethodGet, "/panic", "", http.StatusInternalServerError},
+		{http.MethodPost, "/items", "ok", http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Origin", "https://example.com")
+
+		w := httptest.NewRecorder()
+		gs.router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code, "%s %s", tt.method, tt.path)
+		assert.Equal(t, "https://example.com", w.Header().Get("Access-Control-Allow-Origin"), "%s %s should have CORS headers", tt.method, tt.path)
+	}
+}
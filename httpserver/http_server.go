@@ -4,6 +4,8 @@ import (
 	"net"
 	"net/http"
 	"time"
+
+	"golang.org/x/net/netutil"
 )
 
 // tcpKeepAliveListener sets TCP keep-alive timeouts on accepted
@@ -12,6 +14,7 @@ import (
 // go away.
 type tcpKeepAliveListener struct {
 	*net.TCPListener
+	period time.Duration
 }
 
 func (ln tcpKeepAliveListener) Accept() (c net.Conn, err error) {
@@ -20,18 +23,37 @@ func (ln tcpKeepAliveListener) Accept() (c net.Conn, err error) {
 		return
 	}
 	_ = tc.SetKeepAlive(true)
-	_ = tc.SetKeepAlivePeriod(3 * time.Minute)
+	_ = tc.SetKeepAlivePeriod(ln.period)
 	return tc, nil
 }
 
+const defaultKeepAlivePeriod = 3 * time.Minute
+
 type myHttpServer struct {
 	http.Server
+	keepAlivePeriod time.Duration
+	// 0 means unlimited
+	maxConnections int
 }
 
 func (srv *myHttpServer) Serve(lis net.Listener) error {
-	return srv.Server.Serve(tcpKeepAliveListener{lis.(*net.TCPListener)})
+	return srv.Server.Serve(srv.wrap(lis))
 }
 
 func (srv *myHttpServer) ServeTLS(lis net.Listener, certFile, keyFile string) error {
-	return srv.Server.ServeTLS(tcpKeepAliveListener{lis.(*net.TCPListener)}, certFile, keyFile)
+	return srv.Server.ServeTLS(srv.wrap(lis), certFile, keyFile)
+}
+
+// wrap sets keep-alive on TCP listeners, others (unix, injected) are kept as is.
+// Accepting blocks when max connections is reached
+func (srv *myHttpServer) wrap(lis net.Listener) net.Listener {
+	if tcp, ok := lis.(*net.TCPListener); ok && srv.keepAlivePeriod > 0 {
+		lis = tcpKeepAliveListener{TCPListener: tcp, period: srv.keepAlivePeriod}
+	}
+
+	if srv.maxConnections > 0 {
+		lis = netutil.LimitListener(lis, srv.maxConnections)
+	}
+
+	return lis
 }
@@ -1,48 +1,178 @@
 package httpserver
 
 import (
-	"github.com/200Lab-Education/go-sdk/logger"
-	"github.com/gin-gonic/gin"
-	"math"
+	"errors"
+	"fmt"
+	"math/rand"
 	"os"
+	"strings"
 	"time"
+
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/gin-gonic/gin"
+)
+
+// Values of access-log flag
+const (
+	AccessLogGin  = "gin"
+	AccessLogSDK  = "sdk"
+	AccessLogNone = "none"
 )
 
+// Fields of access log
+const (
+	FieldHostname   = "hostname"
+	FieldStatusCode = "statusCode"
+	FieldLatency    = "latency"
+	FieldClientIP   = "clientIP"
+	FieldMethod     = "method"
+	FieldRoute      = "route"
+	FieldPath       = "path"
+	FieldReferer    = "referer"
+	FieldDataLength = "dataLength"
+	FieldUserAgent  = "userAgent"
+	FieldRequestID  = "requestID"
+	FieldUserID     = "userID"
+)
+
+var defaultAccessLogFields = []string{
+	FieldHostname, FieldStatusCode, FieldLatency, FieldClientIP, FieldMethod, FieldRoute,
+	FieldReferer, FieldDataLength, FieldUserAgent, FieldRequestID, FieldUserID,
+}
+
+// optionalAccessLogFields are logged only when listed explicitly,
+// the raw path may carry ids or tokens that route does not
+var optionalAccessLogFields = []string{FieldPath}
+
+type AccessLogConfig struct {
+	// Empty means the default fields, path must be listed explicitly
+	Fields []string
+	// Ratio (0..1) of successful requests to log, errors are always logged
+	SampleRate float64
+	// Requests to these paths or routes are not logged if successful
+	SkipPaths []string
+}
+
+func DefaultAccessLogConfig() AccessLogConfig {
+	return AccessLogConfig{
+		SampleRate: 1,
+		SkipPaths:  []string{"/healthz", "/readyz"},
+	}
+}
+
+func (cfg AccessLogConfig) Validate() error {
+	if cfg.SampleRate < 0 || cfg.SampleRate > 1 {
+		return errors.New("access log sample rate must be between 0 and 1")
+	}
+
+	_, err := compileAccessLogFields(cfg.Fields)
+	return err
+}
+
+// accessLogRequest is the request being logged, read by field values
+type accessLogRequest struct {
+	c          *gin.Context
+	path       string
+	start      time.Time
+	statusCode int
+}
+
+var accessLogValues = map[string]func(r *accessLogRequest) interface{}{
+	FieldStatusCode: func(r *accessLogRequest) interface{} { return r.statusCode },
+	FieldLatency:    func(r *accessLogRequest) interface{} { return time.Since(r.start).Microseconds() },
+	FieldClientIP:   func(r *accessLogRequest) interface{} { return r.c.ClientIP() },
+	FieldMethod:     func(r *accessLogRequest) interface{} { return r.c.Request.Method },
+	FieldRoute:      func(r *accessLogRequest) interface{} { return r.c.FullPath() },
+	FieldPath:       func(r *accessLogRequest) interface{} { return r.path },
+	FieldReferer:    func(r *accessLogRequest) interface{} { return r.c.Request.Referer() },
+	FieldDataLength: func(r *accessLogRequest) interface{} {
+		if size := r.c.Writer.Size(); size > 0 {
+			return size
+		}
+		return 0
+	},
+	FieldUserAgent: func(r *accessLogRequest) interface{} { return r.c.Request.UserAgent() },
+	FieldRequestID: func(r *accessLogRequest) interface{} { return r.c.GetString(sdkcm.KeyRequestID) },
+	FieldUserID:    func(r *accessLogRequest) interface{} { return currentUserID(r.c) },
+}
+
+type accessLogField struct {
+	name  string
+	value func(r *accessLogRequest) interface{}
+}
+
+// compileAccessLogFields resolves field names once, empty means the default fields
+func compileAccessLogFields(names []string) ([]accessLogField, error) {
+	if len(names) == 0 {
+		names = defaultAccessLogFields
+	}
+
+	fields := make([]accessLogField, 0, len(names))
+	for _, name := range names {
+		if name == FieldHostname {
+			hostname, err := os.Hostname()
+			if err != nil {
+				hostname = "unknown"
+			}
+
+			fields = append(fields, accessLogField{name, func(*accessLogRequest) interface{} { return hostname }})
+			continue
+		}
+
+		value, ok := accessLogValues[name]
+		if !ok {
+			return nil, fmt.Errorf("access log field %q is not supported, use: %s | %s", name,
+				strings.Join(defaultAccessLogFields, " | "), strings.Join(optionalAccessLogFields, " | "))
+		}
+
+		fields = append(fields, accessLogField{name, value})
+	}
+
+	return fields, nil
+}
+
 // A Logger Middleware for gin engine,
 // it keep our logs in formatted.
-// Just demo, need check for better interface
 func Logger(log logger.Logger) gin.HandlerFunc {
+	return AccessLogger(log, DefaultAccessLogConfig())
+}
+
+// AccessLogger logs every request with the SDK logger, latency is in microseconds.
+// Route is the matched route template, ex: /users/:id.
+// It panics when the config is invalid, check it with AccessLogConfig.Validate
+func AccessLogger(log logger.Logger, cfg AccessLogConfig) gin.HandlerFunc {
+	fields, err := compileAccessLogFields(cfg.Fields)
+	if err != nil {
+		panic(err)
+	}
+
+	skipPaths := make(map[string]bool, len(cfg.SkipPaths))
+	for _, p := range cfg.SkipPaths {
+		skipPaths[p] = true
+	}
+
 	return func(c *gin.Context) {
 		// other handler can change c.Path so:
 		path := c.Request.URL.Path
 		start := time.Now()
 		c.Next()
-		stop := time.Since(start)
-		latency := int(math.Ceil(float64(stop.Nanoseconds()) / 1000.0))
+
 		statusCode := c.Writer.Status()
-		clientIP := c.ClientIP()
-		clientUserAgent := c.Request.UserAgent()
-		referer := c.Request.Referer()
-		hostname, err := os.Hostname()
-		if err != nil {
-			hostname = "unknown"
+		isError := statusCode > 399 || len(c.Errors) > 0
+
+		if !isError && (skipPaths[path] || skipPaths[c.FullPath()] || !sampled(cfg.SampleRate)) {
+			return
 		}
-		dataLength := c.Writer.Size()
-		if dataLength < 0 {
-			dataLength = 0
+
+		r := &accessLogRequest{c: c, path: path, start: start, statusCode: statusCode}
+
+		logFields := make(logger.Fields, len(fields))
+		for _, f := range fields {
+			logFields[f.name] = f.value(r)
 		}
 
-		entry := log.Withs(logger.Fields{
-			"hostname":   hostname,
-			"statusCode": statusCode,
-			"latency":    latency, // time to process
-			"clientIP":   clientIP,
-			"method":     c.Request.Method,
-			"path":       path,
-			"referer":    referer,
-			"dataLength": dataLength,
-			"userAgent":  clientUserAgent,
-		})
+		entry := log.Withs(logFields)
 
 		if len(c.Errors) > 0 {
 			entry.Error(c.Errors.ByType(gin.ErrorTypePrivate).String())
@@ -58,3 +188,18 @@ func Logger(log logger.Logger) gin.HandlerFunc {
 		}
 	}
 }
+
+func sampled(rate float64) bool {
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
+
+// currentUserID returns 0 for guests
+func currentUserID(c *gin.Context) uint32 {
+	if u, ok := c.Get("current_user"); ok {
+		if user, ok := u.(sdkcm.User); ok {
+			return user.UserID()
+		}
+	}
+
+	return 0
+}
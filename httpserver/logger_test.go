@@ -0,0 +1,106 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type logEntry struct {
+	level  string
+	fields logger.Fields
+}
+
+// recordLogger records entries written by the access logger
+type recordLogger struct {
+	logger.Logger
+	fields  logger.Fields
+	entries *[]logEntry
+}
+
+func (l *recordLogger) Withs(fields logger.Fields) logger.Logger {
+	return &recordLogger{fields: fields, entries: l.entries}
+}
+
+func (l *recordLogger) record(level string) {
+	*l.entries = append(*l.entries, logEntry{level: level, fields: l.fields})
+}
+
+func (l *recordLogger) Info(...interface{})  { l.record("info") }
+func (l *recordLogger) Warn(...interface{})  { l.record("warn") }
+func (l *recordLogger) Error(...interface{}) { l.record("error") }
+
+func serveAccessLog(cfg AccessLogConfig, paths ...string) []logEntry {
+	gin.SetMode(gin.TestMode)
+
+	var entries []logEntry
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) { c.Set(sdkcm.KeyRequestID, "req-1") })
+	engine.Use(AccessLogger(&recordLogger{entries: &entries}, cfg))
+	engine.GET("/users/:id", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	engine.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	for _, path := range paths {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	return entries
+}
+
+func TestAccessLoggerFields(t *testing.T) {
+	cfg := DefaultAccessLogConfig()
+	cfg.Fields = []string{FieldStatusCode, FieldRoute, FieldPath, FieldDataLength, FieldRequestID}
+
+	entries := serveAccessLog(cfg, "/users/7", "/healthz", "/fail")
+	assert.Len(t, entries, 2, "skipped paths must not be logged")
+
+	assert.Equal(t, "info", entries[0].level, "should be equal")
+	assert.Equal(t, logger.Fields{
+		FieldStatusCode: http.StatusOK,
+		FieldRoute:      "/users/:id",
+		FieldPath:       "/users/7",
+		FieldDataLength: 2,
+		FieldRequestID:  "req-1",
+	}, entries[0].fields, "should be equal")
+
+	assert.Equal(t, "error", entries[1].level, "should be equal")
+	assert.Equal(t, http.StatusInternalServerError, entries[1].fields[FieldStatusCode], "should be equal")
+
+	all := serveAccessLog(DefaultAccessLogConfig(), "/users/7")
+	assert.Len(t, all[0].fields, len(defaultAccessLogFields), "empty fields means the default fields")
+	assert.NotContains(t, all[0].fields, FieldPath, "path must be opt-in")
+}
+
+func TestAccessLoggerSampling(t *testing.T) {
+	cfg := DefaultAccessLogConfig()
+	cfg.SampleRate = 0
+
+	entries := serveAccessLog(cfg, "/users/7", "/fail")
+	assert.Len(t, entries, 1, "errors must always be logged")
+	assert.Equal(t, "error", entries[0].level, "should be equal")
+}
+
+func TestAccessLogConfigValidate(t *testing.T) {
+	tests := []struct {
+		cfg     AccessLogConfig
+		isValid bool
+	}{
+		{DefaultAccessLogConfig(), true},
+		{AccessLogConfig{Fields: []string{FieldHostname, FieldUserID}, SampleRate: 0.5}, true},
+		{AccessLogConfig{Fields: []string{"statuscode"}, SampleRate: 1}, false},
+		{AccessLogConfig{SampleRate: 2}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.cfg.Validate()
+		assert.Equal(t, tt.isValid, err == nil, "config %+v: %v", tt.cfg, err)
+	}
+
+	assert.Panics(t, func() { AccessLogger(nil, AccessLogConfig{Fields: []string{"unknown"}}) }, "should panic on unknown field")
+}
@@ -153,7 +153,7 @@ func RequireRoles(roles ...fmt.Stringer) gin.HandlerFunc {
 			}
 		}
 
-		panic(sdkcm.ErrUnauthorized(nil, sdkcm.ErrNoPermission))
+		panic(sdkcm.ErrForbidden(nil, sdkcm.ErrNoPermission))
 	}
 }
 
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/gin-gonic/gin"
+)
+
+// BodyTooLargeError is returned when reading more than the limit of a body cut by BodyLimit
+type BodyTooLargeError struct {
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("request body exceeds %d bytes", e.Limit)
+}
+
+// ErrBodyTooLarge returns AppError 413 when err is caused by BodyTooLargeError,
+// ex: binding a chunked body larger than the limit
+func ErrBodyTooLarge(err error) (sdkcm.AppError, bool) {
+	var tooLarge *BodyTooLargeError
+	if errors.As(err, &tooLarge) {
+		return sdkcm.ErrRequestTooLarge(tooLarge.Limit), true
+	}
+
+	return sdkcm.AppError{}, false
+}
+
+// BodyLimit rejects requests having body larger than maxBytes with AppError 413.
+// Body without Content-Length is cut at maxBytes, reading more returns BodyTooLargeError
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			AbortWithAppError(c, sdkcm.ErrRequestTooLarge(maxBytes))
+			return
+		}
+
+		c.Request.Body = &limitedBody{ReadCloser: c.Request.Body, remaining: maxBytes, limit: maxBytes}
+		c.Next()
+	}
+}
+
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, &BodyTooLargeError{Limit: b.limit}
+	}
+
+	// read one more byte to know if body exceeds the limit
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+
+	if b.remaining < 0 {
+		return n + int(b.remaining), &BodyTooLargeError{Limit: b.limit}
+	}
+
+	return n, err
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimit(t *testing.T) {
+	logger.InitServLogger(false)
+
+	engine := gin.New()
+	engine.Use(Recovery(logger.GetCurrent().GetLogger("test")), BodyLimit(8))
+	engine.POST("/", func(c *gin.Context) {
+		data, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			panic(err)
+		}
+		c.String(http.StatusOK, string(data))
+	})
+
+	tests := []struct {
+		name    string
+		body    string
+		chunked bool
+		code    int
+	}{
+		{"content length in limit", "12345678", false, http.StatusOK},
+		{"content length over limit", "123456789", false, http.StatusRequestEntityTooLarge},
+		{"chunked in limit", "12345678", true, http.StatusOK},
+		{"chunked over limit", strings.Repeat("x", 100), true, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		if tt.chunked {
+			req.ContentLength = -1
+		}
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.code, w.Code, tt.name)
+		if tt.code == http.StatusRequestEntityTooLarge {
+			assert.Contains(t, w.Body.String(), "request_too_large", tt.name)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Brotli is not supported, it needs a cgo or third-party encoder
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+type CompressConfig struct {
+	// gzip/flate level, ex: gzip.DefaultCompression
+	Level int
+	// Responses smaller than this are sent uncompressed
+	MinSize int
+	// Prefixes of compressible content types
+	ContentTypes []string
+}
+
+func DefaultCompressConfig() CompressConfig {
+	return CompressConfig{
+		Level:   gzip.DefaultCompression,
+		MinSize: 1024,
+		ContentTypes: []string{
+			"application/json", "application/x-ndjson", "application/problem+json",
+			"application/javascript", "application/xml", "text/",
+		},
+	}
+}
+
+// Compress negotiates gzip or deflate with Accept-Encoding. Response is buffered
+// until MinSize bytes to decide, streamed responses (Flush) are compressed at once
+func Compress(cfg CompressConfig) gin.HandlerFunc {
+	if cfg.Level < gzip.HuffmanOnly || cfg.Level > gzip.BestCompression {
+		cfg.Level = gzip.DefaultCompression
+	}
+
+	pools := map[string]*sync.Pool{
+		EncodingGzip: {New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(io.Discard, cfg.Level)
+			return w
+		}},
+		EncodingDeflate: {New: func() interface{} {
+			w, _ := flate.NewWriter(io.Discard, cfg.Level)
+			return w
+		}},
+	}
+
+	return func(c *gin.Context) {
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: c.Writer, cfg: &cfg, encoding: encoding, pool: pools[encoding]}
+		c.Writer = cw
+		defer cw.close()
+
+		c.Next()
+	}
+}
+
+// negotiateEncoding returns the preferred supported encoding, gzip wins on a tie
+func negotiateEncoding(acceptEncoding string) string {
+	best, bestQ := "", 0.0
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, q := parseQuality(part)
+
+		switch name {
+		case EncodingGzip, EncodingDeflate, "*":
+		default:
+			continue
+		}
+
+		if name == "*" {
+			name = EncodingGzip
+		}
+
+		if q > bestQ || (q == bestQ && q > 0 && name == EncodingGzip) {
+			best, bestQ = name, q
+		}
+	}
+
+	return best
+}
+
+// parseQuality parses "gzip;q=0.8"
+func parseQuality(s string) (string, float64) {
+	parts := strings.Split(s, ";")
+	name, q := strings.ToLower(strings.TrimSpace(parts[0])), 1.0
+
+	for _, p := range parts[1:] {
+		p = strings.TrimSpace(p)
+		if strings.HasPrefix(p, "q=") {
+			if v, err := strconv.ParseFloat(p[2:], 64); err == nil {
+				q = v
+			}
+		}
+	}
+
+	return name, q
+}
+
+type resetWriteCloser interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+	Flush() error
+}
+
+type compressWriter struct {
+	gin.ResponseWriter
+	cfg      *CompressConfig
+	encoding string
+	pool     *sync.Pool
+	buf      []byte
+	decided  bool
+	enc      resetWriteCloser
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.cfg.MinSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// Response without body, ex: 204 or 304
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+
+	w.ResponseWriter.Flush()
+}
+
+// decide whether to compress then writes buffered data
+func (w *compressWriter) decide(bigEnough bool) error {
+	w.decided = true
+	header := w.Header()
+
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	if !w.isCompressible() {
+		return w.writeBuffer(w.ResponseWriter)
+	}
+
+	header.Add("Vary", "Accept-Encoding")
+
+	if !bigEnough || w.Status() < http.StatusOK || w.Status() == http.StatusNoContent || w.Status() == http.StatusNotModified {
+		return w.writeBuffer(w.ResponseWriter)
+	}
+
+	header.Del("Content-Length")
+	header.Set("Content-Encoding", w.encoding)
+
+	w.enc = w.pool.Get().(resetWriteCloser)
+	w.enc.Reset(w.ResponseWriter)
+
+	return w.writeBuffer(w.enc)
+}
+
+func (w *compressWriter) isCompressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	for _, t := range w.cfg.ContentTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *compressWriter) writeBuffer(out io.Writer) error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	_, err := out.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+func (w *compressWriter) close() {
+	if !w.decided {
+		_ = w.decide(len(w.buf) >= w.cfg.MinSize)
+	}
+
+	if w.enc != nil {
+		_ = w.enc.Close()
+		w.enc.Reset(io.Discard)
+		w.pool.Put(w.enc)
+		w.enc = nil
+	}
+}
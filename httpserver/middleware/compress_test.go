@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCompressEngine() *gin.Engine {
+	engine := gin.New()
+	engine.Use(Compress(DefaultCompressConfig()), ETag())
+	engine.GET("/big", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"data": strings.Repeat("a", 2048)}) })
+	engine.GET("/small", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"data": "a"}) })
+	return engine
+}
+
+func TestCompress(t *testing.T) {
+	engine := newCompressEngine()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/big", nil)
+	req.Header.Set("Accept-Encoding", "deflate;q=0.5, gzip")
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "should be equal")
+	assert.Equal(t, EncodingGzip, w.Header().Get("Content-Encoding"), "should be equal")
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"), "should be equal")
+
+	r, err := gzip.NewReader(w.Body)
+	assert.Nil(t, err, "must be nil")
+	body, err := ioutil.ReadAll(r)
+	assert.Nil(t, err, "must be nil")
+	assert.Equal(t, `{"data":"`+strings.Repeat("a", 2048)+`"}`, string(body), "should be equal")
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/small", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, "", w.Header().Get("Content-Encoding"), "should be empty")
+	assert.Equal(t, `{"data":"a"}`, w.Body.String(), "should be equal")
+
+	assert.Equal(t, "", negotiateEncoding("br, gzip;q=0"), "should be empty")
+}
+
+func TestETag(t *testing.T) {
+	engine := newCompressEngine()
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/small", nil))
+
+	etag := w.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`), "should have a weak etag")
+
+	// the tag does not depend on the content-coding
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/big", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	engine.ServeHTTP(w, req)
+	gzipETag := w.Header().Get("ETag")
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/big", nil))
+	assert.Equal(t, gzipETag, w.Header().Get("ETag"), "should be equal")
+	assert.True(t, strings.HasPrefix(gzipETag, `W/"`), "gzip and identity responses must not share a strong etag")
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/small", nil)
+	req.Header.Set("If-None-Match", etag)
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotModified, w.Code, "should be equal")
+	assert.Equal(t, "", w.Body.String(), "should be empty")
+}
+
+func TestETagSkipsHeadAndStreams(t *testing.T) {
+	engine := gin.New()
+	engine.Use(ETag())
+	engine.HEAD("/small", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.GET("/stream", func(c *gin.Context) {
+		c.String(http.StatusOK, "chunk")
+		c.Writer.Flush()
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/small", nil))
+	assert.Equal(t, "", w.Header().Get("ETag"), "HEAD should not be tagged")
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	assert.Equal(t, "", w.Header().Get("ETag"), "stream should not be tagged")
+	assert.Equal(t, "chunk", w.Body.String(), "should be equal")
+}
+
+func TestETagWithRecovery(t *testing.T) {
+	logger.InitServLogger(false)
+
+	engine := gin.New()
+	engine.Use(Recovery(logger.GetCurrent().GetLogger("test")), ETag())
+	engine.GET("/panic", func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "should be equal")
+	assert.Equal(t, "", w.Header().Get("ETag"), "error should not be tagged")
+
+	var appErr sdkcm.AppError
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &appErr), "error body must be written")
+	assert.Equal(t, http.StatusInternalServerError, appErr.StatusCode, "should be equal")
+}
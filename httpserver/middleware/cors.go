@@ -1,21 +1,190 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
-func AllowCORS() gin.HandlerFunc {
+const corsRegexPrefix = "regex:"
+
+// CORSConfig for CORS middleware. An allowed origin can be:
+//   - exact: https://example.com
+//   - wildcard subdomain: https://*.example.com
+//   - regex: regex:https://[a-z]+\.example\.com, it must match the whole origin
+//   - any: *
+type CORSConfig struct {
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	MaxAge           time.Duration
+	AllowCredentials bool
+}
+
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead, http.MethodOptions},
+		AllowHeaders: []string{"Origin", "Content-Type", "Content-Length", "Accept", "Accept-Encoding", "Authorization", "X-Requested-With", "X-CSRF-Token", "Cache-Control"},
+		MaxAge:       12 * time.Hour,
+	}
+}
+
+// Validate rejects invalid origin patterns and any origin with credentials,
+// it would let every site make credentialed requests
+func (cfg CORSConfig) Validate() error {
+	if cfg.AllowCredentials {
+		for _, o := range cfg.AllowOrigins {
+			if strings.TrimSpace(o) == "*" {
+				return errors.New("cors origin * cannot be used with credentials, list the allowed origins")
+			}
+		}
+	}
+
+	_, err := compileOrigins(cfg.AllowOrigins)
+	return err
+}
+
+type originMatcher func(origin string) bool
+
+func compileOrigins(origins []string) ([]originMatcher, error) {
+	matchers := make([]originMatcher, 0, len(origins))
+
+	for _, o := range origins {
+		o = strings.TrimSpace(o)
+
+		switch {
+		case o == "":
+			continue
+		case o == "*":
+			matchers = append(matchers, func(string) bool { return true })
+		case strings.HasPrefix(o, corsRegexPrefix):
+			// anchored, so the pattern cannot match a part of an evil origin
+			re, err := regexp.Compile(`^(?:` + strings.TrimPrefix(o, corsRegexPrefix) + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cors origin %q: %s", o, err.Error())
+			}
+			matchers = append(matchers, re.MatchString)
+		case isWildcardSubdomain(o):
+			// https://*.example.com matches https://a.example.com and https://a.b.example.com
+			idx := strings.Index(o, "*.")
+			prefix, suffix := strings.ToLower(o[:idx]), strings.ToLower(o[idx+1:])
+			matchers = append(matchers, func(origin string) bool {
+				return len(origin) > len(prefix)+len(suffix) &&
+					strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix)
+			})
+		case strings.Contains(o, "*"):
+			return nil, fmt.Errorf("invalid cors origin %q: wildcard must be a subdomain, ex: https://*.example.com", o)
+		default:
+			exact := strings.ToLower(strings.TrimSuffix(o, "/"))
+			matchers = append(matchers, func(origin string) bool { return origin == exact })
+		}
+	}
+
+	return matchers, nil
+}
+
+// CORS echoes the request origin when it is allowed, other origins get no CORS headers.
+// It panics when the config is invalid, check it with CORSConfig.Validate
+func CORS(cfg CORSConfig) gin.HandlerFunc {
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+
+	matchers, _ := compileOrigins(cfg.AllowOrigins)
+
+	allowMethods := strings.Join(cfg.AllowMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge / time.Second))
+
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, app_name, app_api_key")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		origin := c.Request.Header.Get("Origin")
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if origin == "" {
+			c.Next()
 			return
 		}
 
-		c.Next()
+		isPreflight := c.Request.Method == http.MethodOptions &&
+			c.Request.Header.Get("Access-Control-Request-Method") != ""
+
+		if !isOriginAllowed(matchers, strings.ToLower(origin)) {
+			if isPreflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+
+			c.Next()
+			return
+		}
+
+		header.Set("Access-Control-Allow-Origin", origin)
+
+		if cfg.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !isPreflight {
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+
+			c.Next()
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+
+		if allowMethods != "" {
+			header.Set("Access-Control-Allow-Methods", allowMethods)
+		}
+
+		if allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+		} else if reqHeaders := c.Request.Header.Get("Access-Control-Request-Headers"); reqHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", reqHeaders)
+		}
+
+		if cfg.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+
+		c.AbortWithStatus(http.StatusNoContent)
 	}
 }
+
+func isWildcardSubdomain(origin string) bool {
+	idx := strings.Index(origin, "*.")
+	return idx == 0 || (idx > 0 && strings.HasSuffix(origin[:idx], "://"))
+}
+
+func isOriginAllowed(matchers []originMatcher, origin string) bool {
+	for _, match := range matchers {
+		if match(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowCORS allows any origin without credentials.
+//
+// Deprecated: use CORS with an origin allowlist
+func AllowCORS() gin.HandlerFunc {
+	cfg := DefaultCORSConfig()
+	cfg.AllowOrigins = []string{"*"}
+	cfg.AllowHeaders = append(cfg.AllowHeaders, "app_name", "app_api_key")
+
+	return CORS(cfg)
+}
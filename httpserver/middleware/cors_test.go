@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.AllowOrigins = []string{"https://example.com", "https://*.example.org", `regex:^https://[a-z]+\.dev$`, `regex:https://app\.example\.net`}
+	cfg.AllowCredentials = true
+
+	engine := gin.New()
+	engine.Use(CORS(cfg))
+	engine.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, c := range []struct {
+		origin  string
+		allowed bool
+	}{
+		{origin: "https://example.com", allowed: true},
+		{origin: "https://api.example.org", allowed: true},
+		{origin: "https://example.org", allowed: false},
+		{origin: "https://app.dev", allowed: true},
+		{origin: "https://evil.com", allowed: false},
+		{origin: "https://app.example.net", allowed: true},
+		{origin: "https://app.example.net.evil.com", allowed: false},
+		{origin: "https://evil.com/https://app.example.net", allowed: false},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", c.origin)
+		engine.ServeHTTP(w, req)
+
+		if c.allowed {
+			assert.Equal(t, c.origin, w.Header().Get("Access-Control-Allow-Origin"), "should be equal")
+			assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"), "should be equal")
+		} else {
+			assert.Equal(t, "", w.Header().Get("Access-Control-Allow-Origin"), "should be empty")
+		}
+
+		assert.Equal(t, "Origin", w.Header().Get("Vary"), "should be equal")
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code, "should be equal")
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), http.MethodPut, "should contain")
+	assert.Equal(t, "43200", w.Header().Get("Access-Control-Max-Age"), "should be equal")
+
+	assert.NotNil(t, CORSConfig{AllowOrigins: []string{"https://a*.com"}}.Validate(), "should be an error")
+}
+
+func TestCORSCredentials(t *testing.T) {
+	assert.NotNil(t, CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true}.Validate(), "should be an error")
+	assert.Nil(t, CORSConfig{AllowOrigins: []string{"*"}}.Validate(), "must be nil")
+
+	engine := gin.New()
+	engine.Use(AllowCORS())
+	engine.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://evil.com")
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, "", w.Header().Get("Access-Control-Allow-Credentials"), "credentials should not be allowed")
+
+	cfg := DefaultCORSConfig()
+	cfg.AllowOrigins = []string{"https://example.com"}
+	cfg.AllowCredentials = true
+
+	engine = gin.New()
+	engine.Use(CORS(cfg))
+	engine.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://evil.com")
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, "", w.Header().Get("Access-Control-Allow-Origin"), "origin should not be reflected")
+	assert.Equal(t, "", w.Header().Get("Access-Control-Allow-Credentials"), "should be empty")
+}
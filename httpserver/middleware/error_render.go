@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ErrorFormatJSON    = "json"
+	ErrorFormatProblem = "problem"
+
+	ContentTypeProblemJSON = "application/problem+json"
+
+	keyErrorRender = "error_render"
+)
+
+// ErrorRenderConfig selects how AppError responses are written
+// by the SDK middlewares (recover, body limit, rate limit)
+type ErrorRenderConfig struct {
+	// json (AppError) | problem (RFC 7807 application/problem+json)
+	Format string
+	// Render root cause (AppError.Log) to clients, dev env only
+	ShowLog bool
+	// Problem type is TypeBaseURI + error code, about:blank if empty.
+	// Ex: https://errors.example.com/
+	TypeBaseURI string
+}
+
+func (cfg ErrorRenderConfig) Validate() error {
+	switch cfg.Format {
+	case "", ErrorFormatJSON, ErrorFormatProblem:
+		return nil
+	}
+
+	return fmt.Errorf("error format %q is not supported, use: json | problem", cfg.Format)
+}
+
+// Problem is an RFC 7807 problem detail with AppError members as extensions
+type Problem struct {
+	Type      string             `json:"type"`
+	Title     string             `json:"title"`
+	Status    int                `json:"status"`
+	Detail    string             `json:"detail,omitempty"`
+	Instance  string             `json:"instance,omitempty"`
+	Code      string             `json:"code,omitempty"`
+	RequestID string             `json:"request_id,omitempty"`
+	Fields    []sdkcm.FieldError `json:"fields,omitempty"`
+	Log       string             `json:"log,omitempty"`
+}
+
+func NewProblem(appErr sdkcm.AppError, instance, typeBaseURI string) Problem {
+	problemType := "about:blank"
+	if typeBaseURI != "" && appErr.Code != "" {
+		problemType = strings.TrimSuffix(typeBaseURI, "/") + "/" + appErr.Code
+	}
+
+	return Problem{
+		Type:      problemType,
+		Title:     http.StatusText(appErr.StatusCode),
+		Status:    appErr.StatusCode,
+		Detail:    appErr.Message,
+		Instance:  instance,
+		Code:      appErr.Code,
+		RequestID: appErr.RequestID,
+		Fields:    appErr.FieldErrors(),
+		Log:       appErr.Log,
+	}
+}
+
+// ErrorRender stores cfg for AbortWithAppError, put it before the
+// middlewares responding errors. It panics when the format is not supported
+func ErrorRender(cfg ErrorRenderConfig) gin.HandlerFunc {
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+
+	return func(c *gin.Context) {
+		c.Set(keyErrorRender, cfg)
+		c.Next()
+	}
+}
+
+// AbortWithAppError responds AppError in the request language with the format
+// set by ErrorRender, AppError JSON without its log when there is no ErrorRender
+func AbortWithAppError(c *gin.Context, appErr sdkcm.AppError) {
+	cfg := ErrorRenderConfig{Format: ErrorFormatJSON}
+	if v, ok := c.Get(keyErrorRender); ok {
+		cfg = v.(ErrorRenderConfig)
+	}
+
+	if appErr.RequestID == "" {
+		appErr.RequestID = GetRequestID(c)
+	}
+
+	appErr = Localize(c, appErr)
+
+	if !cfg.ShowLog {
+		appErr.Log = ""
+	}
+
+	if cfg.Format == ErrorFormatProblem {
+		// gin keeps content type set before rendering
+		c.Header("Content-Type", ContentTypeProblemJSON)
+		c.AbortWithStatusJSON(appErr.StatusCode, NewProblem(appErr, c.Request.URL.Path, cfg.TypeBaseURI))
+		return
+	}
+
+	c.AbortWithStatusJSON(appErr.StatusCode, appErr)
+}
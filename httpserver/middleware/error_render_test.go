@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorRenderProblem(t *testing.T) {
+	logger.InitServLogger(false)
+
+	engine := gin.New()
+	engine.Use(
+		RequestID(),
+		ErrorRender(ErrorRenderConfig{Format: ErrorFormatProblem, TypeBaseURI: "https://errors.example.com/"}),
+		Recovery(logger.GetCurrent().GetLogger("test")),
+	)
+	engine.GET("/users/:id", func(c *gin.Context) {
+		panic(sdkcm.ErrValidation([]sdkcm.FieldError{sdkcm.NewFieldError("id", "required", "id is required")}))
+	})
+	engine.GET("/panic", func(c *gin.Context) { panic(errors.New("dial tcp 10.0.0.1:5432: refused")) })
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	var problem Problem
+	assert.Equal(t, http.StatusBadRequest, w.Code, "should be equal")
+	assert.Equal(t, ContentTypeProblemJSON, w.Header().Get("Content-Type"), "should be equal")
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem), "must be nil")
+	assert.Equal(t, "https://errors.example.com/validation_failed", problem.Type, "should be equal")
+	assert.Equal(t, "Bad Request", problem.Title, "should be equal")
+	assert.Equal(t, http.StatusBadRequest, problem.Status, "should be equal")
+	assert.Equal(t, "/users/1", problem.Instance, "should be equal")
+	assert.Equal(t, 1, len(problem.Fields), "should be equal")
+	assert.NotEmpty(t, problem.RequestID, "should have request id")
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	var body map[string]interface{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body), "must be nil")
+	assert.Equal(t, "about:blank", body["type"], "should be equal")
+	assert.NotContains(t, body, "log", "root cause should be hidden")
+}
+
+func TestErrorRenderHideLog(t *testing.T) {
+	logger.InitServLogger(false)
+
+	engine := gin.New()
+	engine.Use(ErrorRender(ErrorRenderConfig{Format: ErrorFormatJSON}), Recovery(logger.GetCurrent().GetLogger("test")))
+	engine.GET("/panic", func(c *gin.Context) { panic(errors.New("dial tcp 10.0.0.1:5432: refused")) })
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	var appErr sdkcm.AppError
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &appErr), "must be nil")
+	assert.Equal(t, "", appErr.Log, "root cause should be hidden")
+	assert.Equal(t, "internal server error", appErr.Message, "should be equal")
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag hashes body of successful GET responses and answers If-None-Match with 304.
+// An ETag set by the handler is kept. Streamed responses (Flush) are not tagged.
+// HEAD is not tagged, its handler usually writes no body to hash.
+// The tag is weak (W/): it hashes the body before Compress encodes it,
+// so gzip and identity responses share it
+func ETag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		ew := &etagWriter{ResponseWriter: c.Writer}
+		c.Writer = ew
+
+		completed := false
+		defer func() {
+			c.Writer = ew.ResponseWriter
+
+			// handler panicked, drop its partial body so Recovery
+			// writes the error response to the real writer
+			if !completed {
+				ew.buf.Reset()
+			}
+		}()
+
+		c.Next()
+		completed = true
+
+		if ew.passThrough {
+			return
+		}
+
+		header := ew.Header()
+
+		if ew.Status() == http.StatusOK {
+			etag := header.Get("ETag")
+			if etag == "" {
+				sum := sha1.Sum(ew.buf.Bytes())
+				etag = `W/"` + hex.EncodeToString(sum[:]) + `"`
+				header.Set("ETag", etag)
+			}
+
+			if etagMatch(c.GetHeader("If-None-Match"), etag) {
+				header.Del("Content-Length")
+				ew.ResponseWriter.WriteHeader(http.StatusNotModified)
+				ew.ResponseWriter.WriteHeaderNow()
+				return
+			}
+		}
+
+		if ew.buf.Len() > 0 {
+			_, _ = ew.ResponseWriter.Write(ew.buf.Bytes())
+		}
+	}
+}
+
+// etagMatch uses weak comparison as required for If-None-Match
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+type etagWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+	// body is not buffered anymore
+	passThrough bool
+}
+
+func (w *etagWriter) Write(p []byte) (int, error) {
+	if w.passThrough {
+		return w.ResponseWriter.Write(p)
+	}
+
+	return w.buf.Write(p)
+}
+
+func (w *etagWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *etagWriter) WriteHeaderNow() {
+	w.stopBuffering()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *etagWriter) Flush() {
+	w.stopBuffering()
+	w.ResponseWriter.Flush()
+}
+
+func (w *etagWriter) Written() bool {
+	return w.passThrough || w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func (w *etagWriter) stopBuffering() {
+	if w.passThrough {
+		return
+	}
+
+	w.passThrough = true
+	if w.buf.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
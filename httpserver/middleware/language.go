@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/200Lab-Education/go-sdk/util/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+const KeyLanguage = "lang"
+
+// Language stores the language of the request in gin and request contexts.
+// preferred returns language of the user (ex: from profile or token), empty
+// to negotiate Accept-Language with the catalog. Nil catalog is i18n.Default()
+func Language(catalog *i18n.Catalog, preferred func(c *gin.Context) string) gin.HandlerFunc {
+	if catalog == nil {
+		catalog = i18n.Default()
+	}
+
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Language")
+
+		var lang string
+		if preferred != nil {
+			lang = preferred(c)
+		}
+
+		if lang == "" {
+			lang = catalog.Negotiate(c.GetHeader("Accept-Language"))
+		}
+
+		if lang != "" {
+			c.Set(KeyLanguage, lang)
+			c.Request = c.Request.WithContext(i18n.ContextWithLanguage(c.Request.Context(), lang))
+		}
+
+		c.Next()
+	}
+}
+
+// GetLanguage returns language set by Language middleware,
+// otherwise negotiates Accept-Language with i18n.Default()
+func GetLanguage(c *gin.Context) string {
+	if lang := c.GetString(KeyLanguage); lang != "" {
+		return lang
+	}
+
+	return i18n.Default().Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// Localize translates AppError message by its code (error key),
+// English message is kept when there is no translation
+func Localize(c *gin.Context, appErr sdkcm.AppError) sdkcm.AppError {
+	if lang := GetLanguage(c); lang != "" {
+		appErr.Message = i18n.Default().Translate(lang, appErr.Code, appErr.Message)
+	}
+
+	return appErr
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/200Lab-Education/go-sdk/util/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Route label of requests not matching any route, to keep cardinality low
+const unmatchedRoute = "unmatched"
+
+// Metrics records RED metrics of HTTP requests by route template and status
+func Metrics(reg *metrics.Registry, server string) gin.HandlerFunc {
+	requests := reg.Counter("http_requests_total", "Number of HTTP requests.", "server", "method", "route", "status")
+	duration := reg.Histogram("http_request_duration_seconds", "Latency of HTTP requests.", nil, "server", "method", "route", "status")
+	inFlight := reg.Gauge("http_requests_in_flight", "Number of HTTP requests being served.", "server").WithLabelValues(server)
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		status := strconv.Itoa(c.Writer.Status())
+
+		requests.WithLabelValues(server, c.Request.Method, route, status).Inc()
+		duration.WithLabelValues(server, c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/util/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := metrics.NewRegistry(nil)
+
+	engine := gin.New()
+	engine.Use(Metrics(reg, "api"))
+	engine.GET("/users/:id", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	engine.POST("/users", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	engine.GET("/fail", func(c *gin.Context) { c.AbortWithStatus(http.StatusInternalServerError) })
+
+	for _, r := range []struct{ method, path string }{
+		{http.MethodGet, "/users/1"},
+		{http.MethodGet, "/users/2"},
+		{http.MethodPost, "/users"},
+		{http.MethodGet, "/fail"},
+		{http.MethodGet, "/not-found/1"},
+		{http.MethodGet, "/not-found/2"},
+	} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(r.method, r.path, nil))
+	}
+
+	// routes are labeled by template, unknown paths share one label
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`# HELP http_requests_in_flight Number of HTTP requests being served.
+# TYPE http_requests_in_flight gauge
+http_requests_in_flight{server="api"} 0
+# HELP http_requests_total Number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/fail",server="api",status="500"} 1
+http_requests_total{method="GET",route="/users/:id",server="api",status="200"} 2
+http_requests_total{method="GET",route="unmatched",server="api",status="404"} 2
+http_requests_total{method="POST",route="/users",server="api",status="201"} 1
+`), "http_requests_total", "http_requests_in_flight")
+	assert.Nil(t, err, "must be nil")
+
+	duration := reg.Histogram("http_request_duration_seconds", "Latency of HTTP requests.", nil, "server", "method", "route", "status")
+	assert.Equal(t, 4, testutil.CollectAndCount(duration), "should have a series per route and status")
+}
@@ -3,13 +3,16 @@ package middleware
 import (
 	"bytes"
 	"fmt"
+	sdklogger "github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/util/secret"
 	"github.com/gin-gonic/gin"
 	"io"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"net/http/httputil"
-	"os"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -20,11 +23,13 @@ var (
 	slash     = []byte("/")
 )
 
+// PanicLogger logs panics with the SDK logger and responds a JSON AppError
 func PanicLogger() gin.HandlerFunc {
-	return RecoveryWithWriter(os.Stderr)
+	return Recovery(sdklogger.GetCurrent().GetLogger("gin"))
 }
 
-// RecoveryWithWriter returns a middleware for a given writer that recovers from any panics and writes a 500 if there was one.
+// RecoveryWithWriter returns a middleware for a given writer that recovers from any panics
+// and responds a JSON AppError (500 if the panic is not an AppError)
 func RecoveryWithWriter(out io.Writer) gin.HandlerFunc {
 	var logger *log.Logger
 	if out != nil {
@@ -34,23 +39,50 @@ func RecoveryWithWriter(out io.Writer) gin.HandlerFunc {
 		defer func() {
 			if err := recover(); err != nil {
 				if logger != nil {
-					stack := stack(5)
+					stack := stack(3)
 					httprequest, _ := httputil.DumpRequest(c.Request, false)
 					logger.Printf(
 						"[Recovery] %s panic recovered:\n%s\n%s\n%s%s",
-						timeFormat(time.Now()), string(httprequest),
+						timeFormat(time.Now()), redactDump(httprequest),
 						err,
 						stack,
 						string([]byte{27, 91, 48, 109}),
 					)
 				}
-				//c.AbortWithStatus(http.StatusInternalServerError)
+
+				appErr, _, _ := toAppError(err)
+
+				if c.Writer.Written() {
+					c.Abort()
+					return
+				}
+
+				AbortWithAppError(c, appErr)
 			}
 		}()
 		c.Next()
 	}
 }
 
+// redactDump masks sensitive headers in a request dump
+func redactDump(dump []byte) string {
+	lines := strings.Split(string(dump), "\r\n")
+
+	for i := 1; i < len(lines); i++ {
+		idx := strings.Index(lines[i], ":")
+		if idx <= 0 {
+			continue
+		}
+
+		name := lines[i][:idx]
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] || isSensitiveName(name) {
+			lines[i] = name + ": " + secret.Masked
+		}
+	}
+
+	return strings.Join(lines, "\r\n")
+}
+
 func timeFormat(t time.Time) string {
 	var timeString = t.Format("2006/01/02 - 15:04:05")
 	return timeString
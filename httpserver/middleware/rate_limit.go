@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/200Lab-Education/go-sdk/util/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc returns the key to rate limit a request by,
+// empty key skips rate limiting
+type KeyFunc func(c *gin.Context) string
+
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser limits authenticated users by their ID and guests by IP,
+// it must be used after Authorize
+func KeyByUser(c *gin.Context) string {
+	if u, ok := c.Get("current_user"); ok {
+		if requester, ok := u.(sdkcm.User); ok && requester.UserID() != 0 {
+			return "user:" + strconv.FormatUint(uint64(requester.UserID()), 10)
+		}
+	}
+
+	return KeyByIP(c)
+}
+
+// RateLimit rejects requests over the limit with AppError 429 and sets
+// RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and Retry-After headers.
+// Requests are allowed when the limiter fails (ex: redis is down)
+func RateLimit(limiter ratelimit.Limiter, keyFn KeyFunc) gin.HandlerFunc {
+	if keyFn == nil {
+		keyFn = KeyByIP
+	}
+
+	return func(c *gin.Context) {
+		key := keyFn(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		res, err := limiter.Allow(c.Request.Context(), key)
+		if err != nil {
+			_ = c.Error(err)
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+
+		if !res.Allowed {
+			retryAfter := ceilSeconds(res.RetryAfter)
+			header.Set("Retry-After", strconv.Itoa(retryAfter))
+
+			AbortWithAppError(c, sdkcm.ErrTooManyRequests(retryAfter))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/200Lab-Education/go-sdk/util/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubLimiter struct {
+	res  ratelimit.Result
+	err  error
+	keys []string
+}
+
+func (l *stubLimiter) Allow(_ context.Context, key string) (ratelimit.Result, error) {
+	l.keys = append(l.keys, key)
+	return l.res, l.err
+}
+
+func serveRateLimit(limiter ratelimit.Limiter, keyFn KeyFunc) *httptest.ResponseRecorder {
+	engine := gin.New()
+	engine.Use(RequestID(), RateLimit(limiter, keyFn))
+	engine.GET("/", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w
+}
+
+func TestRateLimitAllowed(t *testing.T) {
+	limiter := &stubLimiter{res: ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, ResetAfter: 1500 * time.Millisecond}}
+
+	w := serveRateLimit(limiter, nil)
+	assert.Equal(t, http.StatusOK, w.Code, "should be equal")
+	assert.Equal(t, "10", w.Header().Get("RateLimit-Limit"), "should be equal")
+	assert.Equal(t, "9", w.Header().Get("RateLimit-Remaining"), "should be equal")
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Reset"), "reset should be rounded up")
+	assert.Empty(t, w.Header().Get("Retry-After"), "should be empty when allowed")
+	assert.Equal(t, []string{"ip:192.0.2.1"}, limiter.keys, "should be keyed by ip")
+}
+
+func TestRateLimitRejected(t *testing.T) {
+	limiter := &stubLimiter{res: ratelimit.Result{Limit: 10, ResetAfter: 30 * time.Second, RetryAfter: 2100 * time.Millisecond}}
+
+	w := serveRateLimit(limiter, nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "should be equal")
+	assert.Equal(t, "10", w.Header().Get("RateLimit-Limit"), "should be equal")
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"), "should be equal")
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"), "should be equal")
+	assert.Equal(t, "3", w.Header().Get("Retry-After"), "retry after should be rounded up")
+
+	var appErr sdkcm.AppError
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &appErr), "must be nil")
+	assert.Equal(t, "too_many_requests", appErr.Code, "should be equal")
+	assert.Equal(t, http.StatusTooManyRequests, appErr.StatusCode, "should be equal")
+	assert.Equal(t, w.Header().Get(sdkcm.HeaderRequestID), appErr.RequestID, "should be equal")
+	assert.NotContains(t, w.Body.String(), "ok", "handler must not run")
+}
+
+func TestRateLimitSkipped(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter *stubLimiter
+		keyFn   KeyFunc
+	}{
+		{"limiter fails", &stubLimiter{err: errors.New("redis: connection refused")}, nil},
+		{"empty key", &stubLimiter{}, func(*gin.Context) string { return "" }},
+	}
+
+	for _, tt := range tests {
+		w := serveRateLimit(tt.limiter, tt.keyFn)
+		assert.Equal(t, http.StatusOK, w.Code, tt.name)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"), tt.name)
+	}
+}
@@ -1,63 +1,242 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/200Lab-Education/go-sdk/logger"
 	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/200Lab-Education/go-sdk/util/secret"
 	"github.com/gin-gonic/gin"
-	"github.com/sirupsen/logrus"
-	"net/http"
 )
 
-func Recover(sc ServiceContext) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		logger := sc.Logger("service")
-		lvLogger := logger.GetLevel()
+// Headers never written to logs or reports, also any header containing
+// "token", "secret", "password" or "key"
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+var sensitiveWords = []string{"token", "secret", "password", "key"}
+
+// ErrorReporter sends unexpected errors (panics and 5xx AppError)
+// to an external sink, ex: Sentry. Report must not block for long
+type ErrorReporter interface {
+	Report(ctx context.Context, report *ErrorReport)
+}
+
+type StackFrame = sdkcm.StackFrame
 
+type ErrorReport struct {
+	Err       error
+	AppError  sdkcm.AppError
+	Panic     interface{}
+	RequestID string
+	Method    string
+	// Sensitive query params are redacted
+	URL string
+	// Sensitive headers are redacted
+	Headers http.Header
+	// Where AppError is created, otherwise where the panic happens
+	Stack []StackFrame
+}
+
+// Same as goservice.DevEnv, root causes are only shown to clients in dev env
+const devEnv = "dev"
+
+// Recover responds a JSON AppError for any panic, logs it with the service logger.
+// Unexpected errors are logged with stack and request dump then sent to reporters.
+// Without ErrorRender, AppError.Log is responded when the service runs in dev env
+func Recover(sc ServiceContext, reporters ...ErrorReporter) gin.HandlerFunc {
+	env, ok := sc.(interface{ Env() string })
+	showLog := ok && env.Env() == devEnv
+
+	return recovery(func(c *gin.Context) logger.Logger { return Logger(c, sc, "service") }, showLog, reporters)
+}
+
+// Recovery is Recover with a specific logger, AppError.Log is only responded
+// when it is enabled by ErrorRender
+func Recovery(log logger.Logger, reporters ...ErrorReporter) gin.HandlerFunc {
+	return recovery(func(c *gin.Context) logger.Logger { return withRequestID(c, log) }, false, reporters)
+}
+
+func recovery(getLogger func(c *gin.Context) logger.Logger, showLog bool, reporters []ErrorReporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
 		defer func() {
-			if err := recover(); err != nil {
-				c.Header("Content-Type", "application/json")
-				didFireError := false
-
-				if appErr, ok := err.(sdkcm.AppError); ok {
-					appErr.RootCause = appErr.RootError()
-
-					if appErr.RootCause != nil {
-						appErr.Log = appErr.RootCause.Error()
-					}
-
-					logger.Errorln("App Error: ", appErr)
-
-					c.AbortWithStatusJSON(appErr.StatusCode, appErr)
-
-					if lvLogger == logrus.TraceLevel.String() {
-						panic(err)
-					}
-				} else {
-					var appErr sdkcm.AppError
-
-					if e, ok := err.(error); ok {
-						appErr = sdkcm.AppError{StatusCode: http.StatusInternalServerError, Message: "internal server error"}
-						logger.Errorln(e.Error())
-
-						c.AbortWithStatusJSON(appErr.StatusCode, appErr)
-						didFireError = true
-						panic(err)
-					} else {
-						appErr = sdkcm.AppError{StatusCode: http.StatusInternalServerError, Message: fmt.Sprintf("%s", err)}
-						logger.Errorln(fmt.Sprintf("%s", err))
-
-						c.AbortWithStatusJSON(appErr.StatusCode, appErr)
-						didFireError = true
-						panic(err)
-					}
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			// net/http aborts the response silently with it
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			log := getLogger(c)
+			appErr, err, unexpected := toAppError(rec)
+			appErr.RequestID = GetRequestID(c)
+
+			if isBrokenPipe(err) {
+				log.Warnf("client connection is closed: %s", err.Error())
+				c.Abort()
+				return
+			}
+
+			if unexpected {
+				report := &ErrorReport{
+					Err:       err,
+					AppError:  appErr,
+					Panic:     rec,
+					RequestID: appErr.RequestID,
+					Method:    c.Request.Method,
+					URL:       redactURL(c.Request.URL),
+					Headers:   redactHeaders(c.Request.Header),
+					Stack:     appErr.StackTrace(),
 				}
 
-				if lvLogger == logrus.TraceLevel.String() && !didFireError {
-					panic(err)
+				if len(report.Stack) == 0 {
+					report.Stack = sdkcm.CallerStack(2)
 				}
+
+				log.Withs(logger.Fields{
+					"stack":   report.Stack,
+					"request": dumpRequest(c.Request, report.Headers, report.URL),
+					"status":  appErr.StatusCode,
+				}).Errorf("panic recovered: %s", err.Error())
+
+				for _, r := range reporters {
+					r.Report(c.Request.Context(), report)
+				}
+			} else {
+				if stack := appErr.StackTrace(); len(stack) > 0 {
+					log = log.With("stack", stack)
+				}
+
+				log.Errorln("App Error: ", appErr)
+			}
+
+			// too late to change the response
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+
+			if _, ok := c.Get(keyErrorRender); !ok && showLog {
+				c.Set(keyErrorRender, ErrorRenderConfig{Format: ErrorFormatJSON, ShowLog: true})
 			}
+
+			AbortWithAppError(c, appErr)
 		}()
 
 		c.Next()
 	}
 }
+
+// toAppError returns unexpected when rec is not an AppError or it is a server error
+func toAppError(rec interface{}) (sdkcm.AppError, error, bool) {
+	if recErr, ok := rec.(error); ok {
+		if appErr, ok := ErrBodyTooLarge(recErr); ok {
+			return appErr, recErr, false
+		}
+	}
+
+	var appErr sdkcm.AppError
+	if recErr, ok := rec.(error); ok && errors.As(recErr, &appErr) {
+		// keep invalid fields when the validation error is wrapped
+		appErr = appErr.WithFields(appErr.FieldErrors())
+		appErr.RootCause = appErr.RootError()
+		if appErr.RootCause != nil {
+			appErr.Log = appErr.RootCause.Error()
+		}
+
+		err := appErr.RootCause
+		if err == nil {
+			err = appErr
+		}
+
+		return appErr, err, appErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	err, ok := rec.(error)
+	if !ok {
+		err = fmt.Errorf("%v", rec)
+	}
+
+	appErr = sdkcm.AppError{
+		RootCause:  err,
+		Log:        err.Error(),
+		StatusCode: http.StatusInternalServerError,
+		Message:    "internal server error",
+	}
+
+	return appErr, err, true
+}
+
+func isBrokenPipe(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
+
+func isSensitiveName(name string) bool {
+	name = strings.ToLower(name)
+	for _, w := range sensitiveWords {
+		if strings.Contains(name, w) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redactHeaders(h http.Header) http.Header {
+	result := make(http.Header, len(h))
+
+	for k, v := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] || isSensitiveName(k) {
+			result[k] = []string{secret.Masked}
+			continue
+		}
+		result[k] = v
+	}
+
+	return result
+}
+
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	for k := range query {
+		if isSensitiveName(k) {
+			query.Set(k, secret.Masked)
+		}
+	}
+
+	redacted := *u
+	// keep mask readable
+	redacted.RawQuery = strings.ReplaceAll(query.Encode(), url.QueryEscape(secret.Masked), secret.Masked)
+	return redacted.RequestURI()
+}
+
+func dumpRequest(req *http.Request, headers http.Header, uri string) string {
+	clone := req.Clone(req.Context())
+	clone.Header = headers
+	clone.RequestURI = uri
+
+	dump, err := httputil.DumpRequest(clone, false)
+	if err != nil {
+		return ""
+	}
+
+	return string(dump)
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/200Lab-Education/go-sdk/util/i18n"
+	"github.com/200Lab-Education/go-sdk/util/secret"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReporter struct {
+	reports []*ErrorReport
+}
+
+func (r *mockReporter) Report(_ context.Context, report *ErrorReport) {
+	r.reports = append(r.reports, report)
+}
+
+func TestRecovery(t *testing.T) {
+	logger.InitServLogger(false)
+	reporter := &mockReporter{}
+
+	engine := gin.New()
+	engine.Use(RequestID(), Recovery(logger.GetCurrent().GetLogger("test"), reporter))
+	engine.GET("/panic", func(c *gin.Context) { panic(errors.New("nil pointer")) })
+	engine.GET("/app-error", func(c *gin.Context) { panic(sdkcm.ErrInvalidRequest(errors.New("bad id"))) })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/panic?access_token=abc", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set(sdkcm.HeaderRequestID, "req-1")
+	engine.ServeHTTP(w, req)
+
+	var appErr sdkcm.AppError
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "should be equal")
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &appErr), "must be nil")
+	assert.Equal(t, "internal server error", appErr.Message, "should be equal")
+	assert.Equal(t, "req-1", appErr.RequestID, "should be equal")
+
+	assert.Equal(t, 1, len(reporter.reports), "should be reported")
+	assert.Equal(t, secret.Masked, reporter.reports[0].Headers.Get("Authorization"), "should be masked")
+	assert.Equal(t, "/panic?access_token="+secret.Masked, reporter.reports[0].URL, "should be masked")
+	assert.NotEmpty(t, reporter.reports[0].Stack, "should have stack")
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/app-error", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "should be equal")
+	assert.Equal(t, 1, len(reporter.reports), "client errors should not be reported")
+}
+
+func TestRecoveryLocalized(t *testing.T) {
+	logger.InitServLogger(false)
+	i18n.Default().Add("vi", map[string]string{"ErrUserNotFound": "Không tìm thấy người dùng"})
+
+	engine := gin.New()
+	engine.Use(RequestID(), Recovery(logger.GetCurrent().GetLogger("test")))
+	engine.GET("/user", func(c *gin.Context) {
+		panic(sdkcm.ErrCustom(nil, sdkcm.CustomError("ErrUserNotFound", "user not found")))
+	})
+
+	for lang, msg := range map[string]string{"vi-VN,vi;q=0.9": "Không tìm thấy người dùng", "fr": "user not found"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/user", nil)
+		req.Header.Set("Accept-Language", lang)
+		engine.ServeHTTP(w, req)
+
+		var appErr sdkcm.AppError
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &appErr), "must be nil")
+		assert.Equal(t, msg, appErr.Message, "should be equal")
+		assert.Equal(t, "ErrUserNotFound", appErr.Code, "should be equal")
+	}
+}
+
+type mockServiceContext struct {
+	env string
+}
+
+func (sc *mockServiceContext) Logger(prefix string) logger.Logger {
+	return logger.GetCurrent().GetLogger(prefix)
+}
+func (sc *mockServiceContext) Get(string) (interface{}, bool) { return nil, false }
+func (sc *mockServiceContext) MustGet(string) interface{}     { return nil }
+func (sc *mockServiceContext) Env() string                    { return sc.env }
+
+func TestRecoverShowLogInDevEnv(t *testing.T) {
+	logger.InitServLogger(false)
+
+	for env, log := range map[string]string{"dev": "dial tcp: refused", "prd": ""} {
+		engine := gin.New()
+		engine.Use(Recover(&mockServiceContext{env: env}))
+		engine.GET("/panic", func(c *gin.Context) { panic(errors.New("dial tcp: refused")) })
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+		var appErr sdkcm.AppError
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &appErr), "must be nil")
+		assert.Equal(t, log, appErr.Log, "should be equal in %s env", env)
+	}
+
+	// no ErrorRender and no env, root cause is hidden
+	engine := gin.New()
+	engine.Use(Recovery(logger.GetCurrent().GetLogger("test")))
+	engine.GET("/panic", func(c *gin.Context) { panic(errors.New("dial tcp: refused")) })
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.NotContains(t, w.Body.String(), "refused", "root cause should be hidden")
+}
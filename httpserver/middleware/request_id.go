@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/gin-gonic/gin"
+)
+
+const maxRequestIDLength = 128
+
+// RequestID accepts X-Request-ID from the client or generates a new one,
+// it is stored in gin and request contexts and echoed on the response
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(sdkcm.HeaderRequestID)
+		if !isValidRequestID(id) {
+			id = sdkcm.NewRequestID()
+		}
+
+		c.Set(sdkcm.KeyRequestID, id)
+		c.Request = c.Request.WithContext(sdkcm.ContextWithRequestID(c.Request.Context(), id))
+		c.Header(sdkcm.HeaderRequestID, id)
+
+		c.Next()
+	}
+}
+
+// GetRequestID returns request ID set by RequestID middleware
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(sdkcm.KeyRequestID)
+}
+
+// Logger returns a logger with request ID of current request
+func Logger(c *gin.Context, sc ServiceContext, prefix string) logger.Logger {
+	return withRequestID(c, sc.Logger(prefix))
+}
+
+func withRequestID(c *gin.Context, log logger.Logger) logger.Logger {
+	if id := GetRequestID(c); id != "" {
+		return log.With(sdkcm.KeyRequestID, id)
+	}
+
+	return log
+}
+
+// only printable ASCII is accepted to avoid log injection
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
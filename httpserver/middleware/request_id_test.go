@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	engine := gin.New()
+	engine.Use(RequestID())
+	engine.GET("/", func(c *gin.Context) {
+		// the id in gin context must be the one in request context
+		c.String(http.StatusOK, GetRequestID(c)+"|"+sdkcm.RequestIDFromContext(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		echoed bool
+	}{
+		{"client id", "req-7f3a.b_c:1", true},
+		{"no id", "", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"space", "req 1", false},
+		{"new line", "req-1\nlevel=error", false},
+		{"non ascii", "req-ñ", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			req.Header.Set(sdkcm.HeaderRequestID, tt.header)
+		}
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		id := w.Header().Get(sdkcm.HeaderRequestID)
+		if tt.echoed {
+			assert.Equal(t, tt.header, id, tt.name)
+		} else {
+			assert.NotEmpty(t, id, tt.name)
+			assert.NotEqual(t, tt.header, id, tt.name)
+			assert.True(t, isValidRequestID(id), tt.name)
+		}
+
+		assert.Equal(t, id+"|"+id, w.Body.String(), tt.name)
+	}
+}
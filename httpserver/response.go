@@ -0,0 +1,102 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/200Lab-Education/go-sdk/httpserver/middleware"
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/gin-gonic/gin"
+)
+
+const ContentTypeNDJSON = "application/x-ndjson"
+
+// Items written between flushes of a stream
+var streamFlushEvery = 100
+
+// OK writes data as sdkcm.DataResponse
+func OK[T any](c *gin.Context, data T) {
+	c.JSON(http.StatusOK, sdkcm.NewDataResponse(data))
+}
+
+func Created[T any](c *gin.Context, data T) {
+	resp := sdkcm.NewDataResponse(data)
+	resp.Code = http.StatusCreated
+	c.JSON(http.StatusCreated, resp)
+}
+
+// List writes a page of data with its filter as sdkcm.ListResponse
+func List[T any](c *gin.Context, data []T, filter interface{}, paging *sdkcm.Paging) {
+	c.JSON(http.StatusOK, sdkcm.NewListResponse(data, filter, paging))
+}
+
+// StreamError is the last line of a NDJSON stream failing after it started
+type StreamError struct {
+	Error sdkcm.AppError `json:"error"`
+}
+
+// StreamNDJSON writes items passed to send as NDJSON (a JSON per line) while
+// produce runs, so large exports are not buffered. When produce fails before
+// the first item nothing is written and the caller handles the error as usual,
+// after that the error is written as StreamError and the stream ends.
+// send fails when the client is gone
+func StreamNDJSON[T any](c *gin.Context, produce func(send func(item T) error) error) error {
+	ctx := c.Request.Context()
+	enc := json.NewEncoder(c.Writer)
+	count := 0
+
+	send := func(item T) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if count == 0 {
+			c.Header("Content-Type", ContentTypeNDJSON)
+			c.Header("X-Content-Type-Options", "nosniff")
+			c.Status(http.StatusOK)
+		}
+
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+
+		count++
+		if count%streamFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+
+		return nil
+	}
+
+	err := produce(send)
+
+	if count == 0 {
+		if err == nil {
+			c.Header("Content-Type", ContentTypeNDJSON)
+			c.Status(http.StatusOK)
+			c.Writer.WriteHeaderNow()
+		}
+		return err
+	}
+
+	if err != nil && ctx.Err() == nil {
+		_ = enc.Encode(StreamError{Error: streamAppError(c, err)})
+	}
+
+	c.Writer.Flush()
+	return err
+}
+
+// root cause is never written to a stream
+func streamAppError(c *gin.Context, err error) sdkcm.AppError {
+	var appErr sdkcm.AppError
+	if !errors.As(err, &appErr) {
+		appErr = sdkcm.NewAppErr(err, http.StatusInternalServerError, "internal server error")
+	}
+
+	appErr.Log = ""
+	appErr.RequestID = middleware.GetRequestID(c)
+
+	return middleware.Localize(c, appErr)
+}
@@ -0,0 +1,82 @@
+package httpserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type item struct {
+	ID int `json:"id"`
+}
+
+func TestList(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	List[item](c, nil, map[string]string{"q": "a"}, &sdkcm.Paging{NextCursor: "10", Limit: 2})
+
+	var resp sdkcm.ListResponse[item]
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp), "must be nil")
+	assert.Equal(t, []item{}, resp.Data, "nil data should be an empty list")
+	assert.Equal(t, map[string]interface{}{"q": "a"}, resp.Param, "should be equal")
+	assert.Equal(t, "4k7", resp.Paging.NextCursor, "cursor should be encoded")
+}
+
+func TestStreamNDJSON(t *testing.T) {
+	flushEvery := streamFlushEvery
+	streamFlushEvery = 2
+	t.Cleanup(func() { streamFlushEvery = flushEvery })
+
+	boom := sdkcm.ErrDB(errors.New("connection lost"))
+
+	engine := gin.New()
+	engine.GET("/export", func(c *gin.Context) {
+		_ = StreamNDJSON(c, func(send func(item) error) error {
+			for i := 1; i <= 3; i++ {
+				if err := send(item{ID: i}); err != nil {
+					return err
+				}
+			}
+			return boom
+		})
+	})
+	engine.GET("/fail", func(c *gin.Context) {
+		if err := StreamNDJSON(c, func(send func(item) error) error { return boom }); err != nil {
+			c.JSON(http.StatusBadRequest, err)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code, "should be equal")
+	assert.Equal(t, ContentTypeNDJSON, w.Header().Get("Content-Type"), "should be equal")
+
+	var lines []string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	assert.Equal(t, 4, len(lines), "should be equal")
+	assert.Equal(t, `{"id":1}`, lines[0], "should be equal")
+
+	var last StreamError
+	assert.Nil(t, json.Unmarshal([]byte(lines[3]), &last), "must be nil")
+	assert.Equal(t, "db_error", last.Error.Code, "should be equal")
+	assert.Equal(t, "", last.Error.Log, "root cause should be hidden")
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "error before first item should be handled by the caller")
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"), "should be equal")
+}
@@ -0,0 +1,242 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ClientAuthNone          = "none"
+	ClientAuthRequest       = "request"
+	ClientAuthVerifyIfGiven = "verify-if-given"
+	ClientAuthRequire       = "require"
+)
+
+// Interval to check certificate files for changes
+var tlsReloadInterval = 10 * time.Second
+
+type TLSConfig struct {
+	CertFile     string `json:"tls_cert_file"`
+	KeyFile      string `json:"tls_key_file"`
+	ClientCAFile string `json:"tls_client_ca_file"`
+	// none | request | verify-if-given | require
+	ClientAuth string `json:"tls_client_auth"`
+}
+
+func (c TLSConfig) IsEnabled() bool {
+	return c.CertFile != ""
+}
+
+func (c TLSConfig) check() error {
+	if c.CertFile == "" && c.KeyFile == "" {
+		if c.ClientCAFile != "" {
+			return errors.New("tls client CA needs tls cert and key")
+		}
+		return nil
+	}
+
+	if c.CertFile == "" || c.KeyFile == "" {
+		return errors.New("both tls cert and key are required")
+	}
+
+	if _, err := c.clientAuthType(); err != nil {
+		return err
+	}
+
+	_, err := loadTLSFiles(c)
+	return err
+}
+
+func (c TLSConfig) clientAuthType() (tls.ClientAuthType, error) {
+	switch c.ClientAuth {
+	case "":
+		if c.ClientCAFile != "" {
+			// client CA without mode means mTLS is required
+			return tls.RequireAndVerifyClientCert, nil
+		}
+		return tls.NoClientCert, nil
+	case ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case ClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	}
+
+	return tls.NoClientCert, fmt.Errorf("tls client auth %q is not supported, use: none | request | verify-if-given | require", c.ClientAuth)
+}
+
+type tlsFiles struct {
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+}
+
+func loadTLSFiles(c TLSConfig) (*tlsFiles, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load tls cert: %s", err.Error())
+	}
+
+	files := &tlsFiles{cert: &cert}
+
+	if c.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load tls client CA: %s", err.Error())
+		}
+
+		files.clientCA = x509.NewCertPool()
+		if !files.clientCA.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", c.ClientCAFile)
+		}
+	}
+
+	return files, nil
+}
+
+// certReloader serves certificates from files and reloads them
+// when their modification time changes
+type certReloader struct {
+	config    TLSConfig
+	auth      tls.ClientAuthType
+	mu        *sync.RWMutex
+	files     *tlsFiles
+	modTime   time.Time
+	checkedAt time.Time
+	onError   func(err error)
+}
+
+func newCertReloader(c TLSConfig, onError func(err error)) (*certReloader, error) {
+	auth, err := c.clientAuthType()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := loadTLSFiles(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &certReloader{
+		config:    c,
+		auth:      auth,
+		mu:        new(sync.RWMutex),
+		files:     files,
+		modTime:   latestModTime(c),
+		checkedAt: time.Now(),
+		onError:   onError,
+	}, nil
+}
+
+func latestModTime(c TLSConfig) time.Time {
+	var latest time.Time
+
+	for _, f := range []string{c.CertFile, c.KeyFile, c.ClientCAFile} {
+		if f == "" {
+			continue
+		}
+
+		if st, err := os.Stat(f); err == nil && st.ModTime().After(latest) {
+			latest = st.ModTime()
+		}
+	}
+
+	return latest
+}
+
+// reloadIfChanged keeps the old certificates when the new ones are invalid,
+// ex: cert file is written but key file is not yet
+func (r *certReloader) reloadIfChanged() *tlsFiles {
+	r.mu.RLock()
+	files, checkedAt := r.files, r.checkedAt
+	r.mu.RUnlock()
+
+	if time.Since(checkedAt) < tlsReloadInterval {
+		return files
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkedAt = time.Now()
+
+	modTime := latestModTime(r.config)
+	if !modTime.After(r.modTime) {
+		return r.files
+	}
+
+	newFiles, err := loadTLSFiles(r.config)
+	if err != nil {
+		if r.onError != nil {
+			r.onError(err)
+		}
+		return r.files
+	}
+
+	r.files, r.modTime = newFiles, modTime
+	return r.files
+}
+
+func (r *certReloader) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// ServeTLS without cert files needs a certificate source
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.reloadIfChanged().cert, nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			files := r.reloadIfChanged()
+
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*files.cert},
+				ClientCAs:    files.clientCA,
+				ClientAuth:   r.auth,
+				NextProtos:   []string{"h2", "http/1.1"},
+			}, nil
+		},
+	}
+}
+
+// ClientIdentity is the verified certificate of a mTLS client
+type ClientIdentity struct {
+	CommonName   string   `json:"common_name"`
+	Organization []string `json:"organization,omitempty"`
+	DNSNames     []string `json:"dns_names,omitempty"`
+	// Ex: SPIFFE IDs
+	URIs         []string `json:"uris,omitempty"`
+	SerialNumber string   `json:"serial_number"`
+}
+
+// GetClientIdentity returns identity of the client when its certificate
+// has been verified by the server client CA
+func GetClientIdentity(c *gin.Context) (*ClientIdentity, bool) {
+	if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 || len(c.Request.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	cert := c.Request.TLS.VerifiedChains[0][0]
+
+	identity := &ClientIdentity{
+		CommonName:   cert.Subject.CommonName,
+		Organization: cert.Subject.Organization,
+		DNSNames:     cert.DNSNames,
+		SerialNumber: cert.SerialNumber.String(),
+	}
+
+	for _, uri := range cert.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+	}
+
+	return identity, true
+}
@@ -0,0 +1,205 @@
+package httpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+	tls  tls.Certificate
+}
+
+// issueCert signs a certificate with parent, a nil parent makes a CA
+func issueCert(t *testing.T, cn string, serial int64, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err, "must be nil")
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	assert.Nil(t, err, "must be nil")
+
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err, "must be nil")
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err, "must be nil")
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	tlsCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	assert.Nil(t, err, "must be nil")
+
+	return &testCert{cert: cert, key: key, pem: certPEM, tls: tlsCert}
+}
+
+func writeCert(t *testing.T, c *testCert, certFile, keyFile string, modTime time.Time) {
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	assert.Nil(t, err, "must be nil")
+
+	assert.Nil(t, ioutil.WriteFile(certFile, c.pem, 0644), "must be nil")
+	assert.Nil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600), "must be nil")
+	assert.Nil(t, os.Chtimes(certFile, modTime, modTime), "must be nil")
+	assert.Nil(t, os.Chtimes(keyFile, modTime, modTime), "must be nil")
+}
+
+// serveTLS serves cfg like ginService.Run, without cert files
+func serveTLS(t *testing.T, cfg TLSConfig) string {
+	reloader, err := newCertReloader(cfg, func(err error) { t.Log(err) })
+	assert.Nil(t, err, "must be nil")
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err, "must be nil")
+
+	svr := &http.Server{
+		Handler:   http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		TLSConfig: reloader.tlsConfig(),
+		ErrorLog:  log.New(ioutil.Discard, "", 0),
+	}
+	go func() { _ = svr.ServeTLS(lis, "", "") }()
+	t.Cleanup(func() { _ = svr.Close() })
+
+	return "https://" + lis.Addr().String()
+}
+
+func tlsClient(roots *x509.CertPool, certs ...tls.Certificate) *http.Client {
+	return &http.Client{
+		Timeout: time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certs},
+			DisableKeepAlives: true,
+		},
+	}
+}
+
+func TestTLSHotReload(t *testing.T) {
+	interval := tlsReloadInterval
+	tlsReloadInterval = 0
+	t.Cleanup(func() { tlsReloadInterval = interval })
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	ca := issueCert(t, "ca", 1, nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	writeCert(t, issueCert(t, "server-v1", 2, ca), certFile, keyFile, time.Now().Add(-time.Minute))
+	url := serveTLS(t, TLSConfig{CertFile: certFile, KeyFile: keyFile})
+
+	resp, err := tlsClient(roots).Get(url)
+	assert.Nil(t, err, "must be nil")
+	assert.Equal(t, "server-v1", resp.TLS.PeerCertificates[0].Subject.CommonName, "should be equal")
+	_ = resp.Body.Close()
+
+	writeCert(t, issueCert(t, "server-v2", 3, ca), certFile, keyFile, time.Now())
+
+	resp, err = tlsClient(roots).Get(url)
+	assert.Nil(t, err, "must be nil")
+	assert.Equal(t, "server-v2", resp.TLS.PeerCertificates[0].Subject.CommonName, "should serve reloaded cert")
+	_ = resp.Body.Close()
+}
+
+func TestTLSRequireClientCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	caFile := filepath.Join(dir, "ca.crt")
+
+	ca := issueCert(t, "ca", 1, nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	writeCert(t, issueCert(t, "server", 2, ca), certFile, keyFile, time.Now())
+	assert.Nil(t, ioutil.WriteFile(caFile, ca.pem, 0644), "must be nil")
+
+	url := serveTLS(t, TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile})
+
+	_, err := tlsClient(roots).Get(url)
+	assert.NotNil(t, err, "client without cert must be rejected")
+
+	otherCA := issueCert(t, "other-ca", 10, nil)
+	_, err = tlsClient(roots, issueCert(t, "intruder", 11, otherCA).tls).Get(url)
+	assert.NotNil(t, err, "client cert of another CA must be rejected")
+
+	resp, err := tlsClient(roots, issueCert(t, "client", 3, ca).tls).Get(url)
+	assert.Nil(t, err, "must be nil")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "should be equal")
+	_ = resp.Body.Close()
+}
+
+func TestTLSClientAuthType(t *testing.T) {
+	tests := []struct {
+		cfg  TLSConfig
+		want tls.ClientAuthType
+	}{
+		{TLSConfig{}, tls.NoClientCert},
+		{TLSConfig{ClientCAFile: "ca.crt"}, tls.RequireAndVerifyClientCert},
+		{TLSConfig{ClientCAFile: "ca.crt", ClientAuth: ClientAuthNone}, tls.NoClientCert},
+		{TLSConfig{ClientCAFile: "ca.crt", ClientAuth: ClientAuthVerifyIfGiven}, tls.VerifyClientCertIfGiven},
+		{TLSConfig{ClientAuth: ClientAuthRequest}, tls.RequestClientCert},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.cfg.clientAuthType()
+		assert.Nil(t, err, "must be nil")
+		assert.Equal(t, tt.want, got, "config %+v", tt.cfg)
+	}
+
+	_, err := TLSConfig{ClientAuth: "always"}.clientAuthType()
+	assert.NotNil(t, err, "should be an error")
+}
+
+func TestTLSExplicitClientAuthNone(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	caFile := filepath.Join(dir, "ca.crt")
+
+	ca := issueCert(t, "ca", 1, nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	writeCert(t, issueCert(t, "server", 2, ca), certFile, keyFile, time.Now())
+	assert.Nil(t, ioutil.WriteFile(caFile, ca.pem, 0644), "must be nil")
+
+	url := serveTLS(t, TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, ClientAuth: ClientAuthNone})
+
+	resp, err := tlsClient(roots).Get(url)
+	assert.Nil(t, err, "client without cert must be accepted")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "should be equal")
+	_ = resp.Body.Close()
+}
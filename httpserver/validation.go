@@ -0,0 +1,213 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/200Lab-Education/go-sdk/httpserver/middleware"
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldValidator is implemented by request structs having rules which
+// cannot be expressed by tags, it runs after tag validation succeeds
+type FieldValidator interface {
+	ValidateFields() []sdkcm.FieldError
+}
+
+// Messages of validation rules, %s is the rule param
+var ruleMessages = map[string]string{
+	"required": "is required",
+	"email":    "must be a valid email",
+	"url":      "must be a valid URL",
+	"uri":      "must be a valid URI",
+	"uuid":     "must be a valid UUID",
+	"numeric":  "must be numeric",
+	"alphanum": "must contain only letters and numbers",
+	"min":      "must be at least %s",
+	"max":      "must be at most %s",
+	"len":      "must have length %s",
+	"gt":       "must be greater than %s",
+	"gte":      "must be greater than or equal to %s",
+	"lt":       "must be less than %s",
+	"lte":      "must be less than or equal to %s",
+	"eq":       "must be equal to %s",
+	"ne":       "must not be equal to %s",
+	"oneof":    "must be one of: %s",
+	"eqfield":  "must be equal to %s",
+}
+
+var ruleMessagesMu = new(sync.RWMutex)
+
+// validate returns validator engine of gin, it is shared with the whole app
+// so it is never reconfigured here, fields are renamed by fieldPath instead
+func validate() *validator.Validate {
+	v, _ := binding.Validator.Engine().(*validator.Validate)
+	return v
+}
+
+// RegisterRule adds a custom validation tag, message can have %s for the tag param.
+// Ex: RegisterRule("phone", isPhone, "must be a valid phone number")
+func RegisterRule(tag string, fn validator.Func, message string) error {
+	v := validate()
+	if v == nil {
+		return errors.New("gin validator engine is not go-playground/validator")
+	}
+
+	if err := v.RegisterValidation(tag, fn); err != nil {
+		return err
+	}
+
+	ruleMessagesMu.Lock()
+	ruleMessages[tag] = message
+	ruleMessagesMu.Unlock()
+
+	return nil
+}
+
+// Bind binds request by its method and Content-Type then validates it,
+// the returned error is an AppError listing invalid fields
+func Bind(c *gin.Context, obj interface{}) error {
+	return bindWith(c, obj, binding.Default(c.Request.Method, c.ContentType()))
+}
+
+func BindJSON(c *gin.Context, obj interface{}) error {
+	return bindWith(c, obj, binding.JSON)
+}
+
+func BindQuery(c *gin.Context, obj interface{}) error {
+	return bindWith(c, obj, binding.Query)
+}
+
+func BindForm(c *gin.Context, obj interface{}) error {
+	return bindWith(c, obj, binding.Form)
+}
+
+func bindWith(c *gin.Context, obj interface{}, b binding.Binding) error {
+	if err := c.ShouldBindWith(obj, b); err != nil {
+		return validationError(err, obj)
+	}
+
+	if fv, ok := obj.(FieldValidator); ok {
+		if fields := fv.ValidateFields(); len(fields) > 0 {
+			return sdkcm.ErrValidation(fields)
+		}
+	}
+
+	return nil
+}
+
+// ValidationError converts binding and validator errors to an AppError,
+// fields are named as in the struct. Bind names them by their json or form tag
+func ValidationError(err error) error {
+	return validationError(err, nil)
+}
+
+func validationError(err error, obj interface{}) error {
+	if appErr, ok := middleware.ErrBodyTooLarge(err); ok {
+		return appErr
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]sdkcm.FieldError, len(validationErrs))
+		for i, fe := range validationErrs {
+			fields[i] = sdkcm.NewFieldError(fieldPath(fe, obj), fe.Tag(), ruleMessage(fe.Tag(), fe.Param()))
+		}
+
+		return sdkcm.ErrValidation(fields)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return sdkcm.ErrValidation([]sdkcm.FieldError{
+			sdkcm.NewFieldError(typeErr.Field, "type", "must be "+typeErr.Type.String()),
+		})
+	}
+
+	return sdkcm.ErrInvalidRequest(err)
+}
+
+// fieldPath removes struct name from the namespace and names the fields of obj
+// by their json, form or uri tag, ex: User.Address.City => address.city
+func fieldPath(fe validator.FieldError, obj interface{}) string {
+	ns := fe.StructNamespace()
+	idx := strings.Index(ns, ".")
+	if idx < 0 {
+		return fe.Field()
+	}
+
+	segments := strings.Split(ns[idx+1:], ".")
+	if obj == nil {
+		return strings.Join(segments, ".")
+	}
+
+	t := reflect.TypeOf(obj)
+	for i, segment := range segments {
+		name, index := segment, ""
+		if idx := strings.Index(segment, "["); idx >= 0 {
+			name, index = segment[:idx], segment[idx:]
+		}
+
+		t = indirectType(t)
+		if t.Kind() != reflect.Struct {
+			break
+		}
+
+		f, ok := t.FieldByName(name)
+		if !ok {
+			break
+		}
+
+		segments[i] = tagName(f) + index
+
+		// element type of slices, arrays and maps for each index
+		t = f.Type
+		for n := strings.Count(index, "["); n > 0; n-- {
+			t = indirectType(t).Elem()
+		}
+	}
+
+	return strings.Join(segments, ".")
+}
+
+func tagName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name := strings.Split(f.Tag.Get(tag), ",")[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return f.Name
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+func ruleMessage(rule, param string) string {
+	ruleMessagesMu.RLock()
+	msg, ok := ruleMessages[rule]
+	ruleMessagesMu.RUnlock()
+
+	if !ok {
+		return fmt.Sprintf("does not satisfy rule %s", rule)
+	}
+
+	if strings.Contains(msg, "%s") {
+		return fmt.Sprintf(msg, param)
+	}
+
+	return msg
+}
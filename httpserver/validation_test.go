@@ -0,0 +1,102 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/httpserver/middleware"
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+type signUpRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+	Age      int    `json:"age"`
+}
+
+func (r *signUpRequest) ValidateFields() []sdkcm.FieldError {
+	if r.Age != 0 && r.Age < 13 {
+		return []sdkcm.FieldError{sdkcm.NewFieldError("age", "age", "must be at least 13")}
+	}
+	return nil
+}
+
+func bindBody(body string) error {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	return Bind(c, &signUpRequest{})
+}
+
+func TestBind(t *testing.T) {
+	err := bindBody(`{"email":"abc","password":"123"}`)
+	appErr, ok := err.(sdkcm.AppError)
+	assert.True(t, ok, "should be an AppError")
+	assert.Equal(t, http.StatusBadRequest, appErr.StatusCode, "should be equal")
+	assert.Equal(t, []sdkcm.FieldError{
+		{Field: "email", Rule: "email", Message: "must be a valid email", Code: "field_email"},
+		{Field: "password", Rule: "min", Message: "must be at least 6", Code: "field_min"},
+	}, appErr.FieldErrors(), "should be equal")
+
+	appErr = bindBody(`{"email":"a@b.co","password":"123456","age":"ten"}`).(sdkcm.AppError)
+	assert.Equal(t, "age", appErr.FieldErrors()[0].Field, "should be equal")
+	assert.Equal(t, "type", appErr.FieldErrors()[0].Rule, "should be equal")
+
+	appErr = bindBody(`{"email":"a@b.co","password":"123456","age":10}`).(sdkcm.AppError)
+	assert.Equal(t, "field_age", appErr.FieldErrors()[0].Code, "should be equal")
+
+	assert.Nil(t, bindBody(`{"email":"a@b.co","password":"123456","age":20}`), "must be nil")
+}
+
+type orderRequest struct {
+	Items []*orderItem `json:"items" binding:"required,dive"`
+	Note  string       `form:"note" binding:"max=3"`
+}
+
+type orderItem struct {
+	ProductID string `json:"product_id" binding:"required"`
+}
+
+func TestBindNestedFieldNames(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"items":[{"product_id":"1"},{}],"Note":"long"}`))
+
+	appErr := BindJSON(c, &orderRequest{}).(sdkcm.AppError)
+	fields := appErr.FieldErrors()
+	assert.Equal(t, 2, len(fields), "should be equal")
+	assert.Equal(t, "items[1].product_id", fields[0].Field, "should be equal")
+	assert.Equal(t, "note", fields[1].Field, "should be equal")
+}
+
+func TestBindKeepsGinValidator(t *testing.T) {
+	assert.NotNil(t, bindBody(`{}`), "must not be nil")
+
+	// validator of gin is shared by the app, its field names are unchanged
+	err := binding.Validator.ValidateStruct(&signUpRequest{})
+	var validationErrs validator.ValidationErrors
+	assert.ErrorAs(t, err, &validationErrs, "should be validation errors")
+	assert.Equal(t, "Email", validationErrs[0].Field(), "should be equal")
+}
+
+func TestBindBodyTooLarge(t *testing.T) {
+	engine := gin.New()
+	engine.Use(middleware.BodyLimit(16))
+	engine.POST("/", func(c *gin.Context) {
+		appErr := BindJSON(c, &signUpRequest{}).(sdkcm.AppError)
+		c.JSON(appErr.StatusCode, appErr)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"a@b.co","password":"123456"}`))
+	req.ContentLength = -1
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, "chunked body over the limit should be 413")
+}
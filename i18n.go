@@ -0,0 +1,41 @@
+// Copyright (c) 2019, Viet Tran, 200Lab Team.
+
+package goservice
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/200Lab-Education/go-sdk/util/i18n"
+)
+
+// Load translations of error messages into i18n.Default(), it is called by Init
+func (s *service) loadMessages() error {
+	if s.i18nDir == "" {
+		return nil
+	}
+
+	if err := i18n.Default().LoadDir(s.i18nDir); err != nil {
+		return fmt.Errorf("cannot load i18n messages: %s", err.Error())
+	}
+
+	return nil
+}
+
+// validateI18nDir only checks the directory, messages are loaded by Init
+func (s *service) validateI18nDir() error {
+	if s.i18nDir == "" {
+		return nil
+	}
+
+	st, err := os.Stat(s.i18nDir)
+	if err != nil {
+		return fmt.Errorf("cannot load i18n messages: %s", err.Error())
+	}
+
+	if !st.IsDir() {
+		return fmt.Errorf("i18n dir %s is not a directory", s.i18nDir)
+	}
+
+	return nil
+}
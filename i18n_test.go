@@ -0,0 +1,28 @@
+package goservice
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/util/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessagesLoadedByInit(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "vi.json"), []byte(`{"ErrI18nInitTest":"Lỗi"}`), 0644), "must be nil")
+
+	s := newTestService()
+	s.i18nDir = dir
+	s.states = newComponentStates()
+
+	assert.Nil(t, s.validateI18nDir(), "must be nil")
+	assert.Equal(t, "fallback", i18n.Default().Translate("vi", "ErrI18nInitTest", "fallback"), "should not be loaded by validation")
+
+	assert.Nil(t, s.Init(), "must be nil")
+	assert.Equal(t, "Lỗi", i18n.Default().Translate("vi", "ErrI18nInitTest", "fallback"), "should be loaded by Init")
+
+	s.i18nDir = filepath.Join(dir, "vi.json")
+	assert.NotNil(t, s.validateI18nDir(), "file is not a directory")
+}
@@ -3,8 +3,11 @@
 package goservice
 
 import (
+	"context"
+	"github.com/200Lab-Education/go-sdk/httpserver/middleware"
 	"github.com/200Lab-Education/go-sdk/logger"
 	"github.com/gin-gonic/gin"
+	"net"
 )
 
 // Convenience option method for creating/initializing a service
@@ -13,6 +16,9 @@ type Option func(*service)
 // HTTP Server Handler for register some routes and gin handlers
 type HttpServerHandler = func(*gin.Engine)
 
+// Reporter of panics caught by HTTP servers, ex: Sentry
+type ErrorReporter = middleware.ErrorReporter
+
 // A kind of server job
 type Function func(ServiceContext) error
 
@@ -32,6 +38,18 @@ type HasPrefix interface {
 	Get() interface{}
 }
 
+// StartNotifier is implemented by components whose Run blocks while serving.
+// Started is closed when the current (or next) Run is serving, ex: listening
+type StartNotifier interface {
+	Started() <-chan struct{}
+}
+
+// HasDependencies is implemented by init components which need
+// other init components (by prefix) to be run before them
+type HasDependencies interface {
+	Dependencies() []string
+}
+
 // The heart of SDK, Service represents for a real micro service
 // with its all components
 type Service interface {
@@ -43,8 +61,13 @@ type Service interface {
 	Version() string
 	// Gin HTTP Server wrapper
 	HTTPServer() HttpServer
+	// Gin HTTP Server registered by WithHttpServer,
+	// empty prefix returns the default one
+	HTTPServerWithPrefix(prefix string) (HttpServer, bool)
 	// Init with options, they can be db connections or
-	// anything the service need handle before starting
+	// anything the service need handle before starting.
+	// Components run in dependency order, a cycle returns an error.
+	// Invalid config of all components is returned as a *ValidationError
 	Init() error
 	// This method returns service if it is registered on discovery
 	IsRegistered() bool
@@ -52,9 +75,11 @@ type Service interface {
 	// It will be stopped if any service return error
 	Start() error
 	// Stop service and its all component.
+	// Components missing the shutdown deadline are force-closed
 	Stop()
 	// Method export all flags to std/terminal
-	// We might use: "> .env" to move its content .env file
+	// We might use: "> .env" to move its content .env file.
+	// Flag outenv-format selects the format: env | yaml | json | toml
 	OutEnv()
 }
 
@@ -80,6 +105,32 @@ type Runnable interface {
 	Stop() <-chan bool
 }
 
+// HealthChecker is implemented by components which can report their health,
+// a returned error marks the component as down and the service as not ready
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// Validator is implemented by components which can check their config
+// before running. Service validates all components right after parsing flags
+// and reports all problems together
+type Validator interface {
+	Validate() error
+}
+
+// ForceStopper is implemented by components which can release their
+// resources immediately when they miss the shutdown deadline
+type ForceStopper interface {
+	ForceStop() error
+}
+
+// Reloadable is implemented by components which can apply new config
+// without restarting. Service calls Reload on SIGHUP after re-reading
+// env file and flags
+type Reloadable interface {
+	Reload() error
+}
+
 // GIN HTTP server for REST API
 type HttpServer interface {
 	Runnable
@@ -89,4 +140,14 @@ type HttpServer interface {
 	//GetConfig() http_server.Config
 	// URI that the server is listening
 	URI() string
+	// Server only runs when it has handlers
+	IsEnabled() bool
+	// Send panics recovered by the default middleware stack to a reporter
+	AddErrorReporter(r ErrorReporter)
+	// Serve on a pre-made listener instead of the bind address,
+	// ex: systemd socket activation or tests. Call it before Start
+	SetListener(lis net.Listener)
+	// Render root cause (AppError.Log) of error responses to clients,
+	// the service shows it in dev env only
+	ShowErrorLog(show bool)
 }
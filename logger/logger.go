@@ -4,7 +4,9 @@
 package logger
 
 import (
+	"context"
 	"fmt"
+	"github.com/200Lab-Education/go-sdk/sdkcm"
 	"github.com/sirupsen/logrus"
 	"log"
 	"runtime"
@@ -60,6 +62,18 @@ type Logger interface {
 	GetLevel() string
 }
 
+// WithContext returns log with the request ID carried by ctx
+// (set by RequestID middleware or by pubsub events), ex:
+//
+//	logger.WithContext(c.Request.Context(), sc.Logger("user")).Infoln("user created")
+func WithContext(ctx context.Context, log Logger) Logger {
+	if id := sdkcm.RequestIDFromContext(ctx); id != "" {
+		return log.With(sdkcm.KeyRequestID, id)
+	}
+
+	return log
+}
+
 type logger struct {
 	*logrus.Entry
 }
@@ -105,7 +119,7 @@ func (l *logger) Debugf(format string, args ...interface{}) {
 
 func (l *logger) Print(args ...interface{}) {
 	if l.Entry.Logger.Level >= logrus.DebugLevel {
-		l.debugSrc().Debug(args)
+		l.debugSrc().Debug(args...)
 	}
 }
 
@@ -0,0 +1,24 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithContext(t *testing.T) {
+	s := NewAppLogService(&Config{DefaultLevel: "info"})
+	buf := new(bytes.Buffer)
+	s.logger.Out = buf
+
+	ctx := sdkcm.ContextWithRequestID(context.Background(), "req-1")
+	WithContext(ctx, s.GetLogger("test")).Infoln("with request")
+	assert.Contains(t, buf.String(), "request_id=req-1", "should have request id")
+
+	buf.Reset()
+	WithContext(context.Background(), s.GetLogger("test")).Infoln("without request")
+	assert.NotContains(t, buf.String(), "request_id", "should not have request id")
+}
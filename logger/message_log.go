@@ -73,6 +73,20 @@ func (m *messageLogger) Run() error {
 	return m.Configure()
 }
 
+// Reload applies log level flag and reopens log file,
+// it is called on SIGHUP after the file has been rotated
+func (m *messageLogger) Reload() error {
+	if err := m.stdLogger.Reload(); err != nil {
+		return err
+	}
+
+	if file, ok := m.logger.Out.(*reloadFile); ok {
+		return file.ReOpen()
+	}
+
+	return nil
+}
+
 func (m *messageLogger) Stop() <-chan bool {
 	c := make(chan bool)
 
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStdLoggerReload(t *testing.T) {
+	s := NewAppLogService(&Config{DefaultLevel: "info"})
+	assert.Nil(t, s.Configure(), "must be nil")
+
+	// flag value changed by env file or config on SIGHUP
+	s.logLevel = "warn"
+	assert.Nil(t, s.Reload(), "must be nil")
+	assert.Equal(t, logrus.WarnLevel, s.logger.GetLevel(), "should be equal")
+
+	s.logLevel = "verbose"
+	assert.NotNil(t, s.Reload(), "should be an error")
+}
+
+func TestMessageLoggerReopensFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	m := NewMessageLogService(&Config{DefaultLevel: "info"})
+	m.logPath = path
+	assert.Nil(t, m.Configure(), "must be nil")
+
+	m.GetLogger("test").Infoln("before rotate")
+
+	// logrotate moves the file then sends SIGHUP
+	assert.Nil(t, os.Rename(path, path+".1"), "must be nil")
+	assert.Nil(t, m.Reload(), "must be nil")
+
+	m.GetLogger("test").Infoln("after rotate")
+	<-m.Stop()
+
+	rotated, _ := ioutil.ReadFile(path + ".1")
+	current, _ := ioutil.ReadFile(path)
+	assert.Contains(t, string(rotated), "before rotate", "should be in the rotated file")
+	assert.Contains(t, string(current), "after rotate", "should be in the new file")
+	assert.NotContains(t, string(current), "before rotate", "should not be in the new file")
+}
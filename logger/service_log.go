@@ -22,6 +22,8 @@ type Config struct {
 
 type ServiceLogger interface {
 	GetLogger(prefix string) Logger
+	// Change level of all loggers at runtime
+	SetLevel(level string) error
 }
 
 // A default app logger
@@ -85,6 +87,21 @@ func (s *stdLogger) Configure() error {
 }
 
 func (s *stdLogger) Run() error { return s.Configure() }
+
+func (s *stdLogger) SetLevel(level string) error {
+	lv, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	s.logLevel = level
+	s.logger.SetLevel(lv)
+	return nil
+}
+
+// Reload applies log level flag again, it is called on SIGHUP
+func (s *stdLogger) Reload() error { return s.SetLevel(s.logLevel) }
+
 func (s *stdLogger) Stop() <-chan bool {
 	c := make(chan bool)
 	go func() { c <- true }()
@@ -0,0 +1,68 @@
+// Copyright (c) 2019, Viet Tran, 200Lab Team.
+
+package goservice
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/200Lab-Education/go-sdk/util/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics of all components are exposed in Prometheus text format,
+// plugins and application register theirs with metrics.Default()
+type metricsEndpoint struct {
+	path string
+	// prefix of the HTTP server serving metrics, empty for the admin address
+	server string
+}
+
+// Label samples with service name and version, add runtime metrics
+func (s *service) initMetrics() {
+	metrics.Default().SetConstLabels(map[string]string{
+		"service": s.name,
+		"version": s.version,
+	})
+	metrics.Default().RegisterRuntimeMetrics()
+}
+
+func (s *service) validateMetrics() error {
+	if s.metrics.path == "" {
+		return nil
+	}
+
+	if s.metrics.server == "" {
+		return nil
+	}
+
+	server, ok := s.httpServers[s.metrics.server]
+	if !ok {
+		return fmt.Errorf("metrics server %q is not registered, use WithHttpServer", s.metrics.server)
+	}
+
+	// a random port cannot be scraped
+	if _, port, err := net.SplitHostPort(server.URI()); err == nil && port == "0" {
+		return fmt.Errorf("metrics server %q needs a port, set -%s-port", s.metrics.server, s.metrics.server)
+	}
+
+	return nil
+}
+
+// Mount metrics endpoint on the HTTP server set by metrics-server,
+// otherwise it is served by the admin server
+func (s *service) mountMetrics() {
+	if s.metrics.path == "" || s.metrics.server == "" {
+		return
+	}
+
+	server, ok := s.httpServers[s.metrics.server]
+	if !ok {
+		return
+	}
+
+	handler := metrics.Default().Handler()
+	server.AddHandler(func(engine *gin.Engine) {
+		engine.GET(s.metrics.path, gin.WrapH(handler))
+	})
+}
@@ -0,0 +1,43 @@
+package goservice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMetricsServer(t *testing.T) {
+	tests := []struct {
+		args  []string
+		valid bool
+	}{
+		{[]string{}, true},
+		{[]string{"-metrics-server=internal"}, false},
+		{[]string{"-metrics-server=internal", "-internal-port=9100"}, true},
+		{[]string{"-metrics-server=internal", "-internal-addr=unix:///tmp/metrics.sock"}, true},
+		{[]string{"-metrics-server=missing", "-internal-port=9100"}, false},
+		{[]string{"-metrics-path=", "-metrics-server=internal"}, true},
+	}
+
+	for _, tt := range tests {
+		s := newIsolatedService(t, tt.args, WithName("x"), WithHttpServer("internal"))
+		assert.Equal(t, tt.valid, s.validateMetrics() == nil, "args %v", tt.args)
+	}
+}
+
+func TestMetricsServedByAdmin(t *testing.T) {
+	s := newIsolatedService(t, []string{}, WithName("x"))
+
+	admin := newAdminServer(s)
+	assert.Nil(t, admin.Configure(), "must be nil")
+
+	w := httptest.NewRecorder()
+	admin.svr.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "should be served on the admin address")
+	assert.Contains(t, w.Body.String(), "go_goroutines", "should be equal")
+
+	s.mountMetrics()
+	assert.False(t, s.httpServer.IsEnabled(), "should not enable the default server")
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"github.com/200Lab-Education/go-sdk/logger"
 	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/200Lab-Education/go-sdk/util/secret"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -65,11 +66,16 @@ func (s *s3) Name() string {
 
 func (s *s3) InitFlags() {
 	flag.StringVar(&s.cfg.s3ApiKey, fmt.Sprintf("%s-%s", s.GetPrefix(), "api-key"), "", "S3 API key")
-	flag.StringVar(&s.cfg.s3ApiSecret, fmt.Sprintf("%s-%s", s.GetPrefix(), "api-secret"), "", "S3 API secret key")
+	secret.StringVar(&s.cfg.s3ApiSecret, fmt.Sprintf("%s-%s", s.GetPrefix(), "api-secret"), "", "S3 API secret key")
 	flag.StringVar(&s.cfg.s3Region, fmt.Sprintf("%s-%s", s.GetPrefix(), "region"), "", "S3 region")
 	flag.StringVar(&s.cfg.s3Bucket, fmt.Sprintf("%s-%s", s.GetPrefix(), "bucket"), "", "S3 bucket")
 }
 
+// Implement Validator interface
+func (s *s3) Validate() error {
+	return s.cfg.check()
+}
+
 func (s *s3) Configure() error {
 	s.logger = logger.GetCurrent().GetLogger(s.Name())
 
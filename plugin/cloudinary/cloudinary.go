@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/200Lab-Education/go-sdk/logger"
 	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/200Lab-Education/go-sdk/util/secret"
 )
 
 var (
@@ -55,10 +56,15 @@ func (cd *cloudinary) Name() string {
 
 func (cd *cloudinary) InitFlags() {
 	flag.StringVar(&cd.config.apiKey, fmt.Sprintf("%s-%s", cd.GetPrefix(), "api-key"), "", "Cloudinary api key")
-	flag.StringVar(&cd.config.apiSecret, fmt.Sprintf("%s-%s", cd.GetPrefix(), "api-secret"), "", "Cloudinary api secret")
+	secret.StringVar(&cd.config.apiSecret, fmt.Sprintf("%s-%s", cd.GetPrefix(), "api-secret"), "", "Cloudinary api secret")
 	flag.StringVar(&cd.config.cloudName, fmt.Sprintf("%s-%s", cd.GetPrefix(), "cloud-name"), "", "Cloudinary cloud name")
 }
 
+// Implement Validator interface
+func (cd *cloudinary) Validate() error {
+	return cd.config.check()
+}
+
 func (cd *cloudinary) Configure() error {
 	cd.logger = logger.GetCurrent().GetLogger(cd.Name())
 
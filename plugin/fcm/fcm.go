@@ -8,9 +8,9 @@ package fcm
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/util/secret"
 	gofcm "github.com/NaySoftware/go-fcm"
 )
 
@@ -66,7 +66,7 @@ func (s *fcmClient) Name() string {
 }
 
 func (s *fcmClient) InitFlags() {
-	flag.StringVar(&s.apiKey, fmt.Sprintf("%s-api-key", s.Name()), "", "firebase cloud messaging api key")
+	secret.StringVar(&s.apiKey, fmt.Sprintf("%s-api-key", s.Name()), "", "firebase cloud messaging api key")
 }
 
 func (s *fcmClient) Configure() error {
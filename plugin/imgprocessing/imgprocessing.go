@@ -57,6 +57,11 @@ func (imgproc *imgProcessing) Stop() <-chan bool {
 	return c
 }
 
+// Implement Validator interface
+func (imgproc *imgProcessing) Validate() error {
+	return imgproc.cfg.check()
+}
+
 func (imgproc *imgProcessing) Configure() error {
 	imgproc.logger = logger.GetCurrent().GetLogger(imgproc.Name())
 
@@ -76,6 +76,15 @@ func (j *jaeger) InitFlags() {
 	)
 }
 
+// Implement Validator interface
+func (j *jaeger) Validate() error {
+	if j.sampleTraceRating < 0 || j.sampleTraceRating > 1 {
+		return fmt.Errorf("jaeger-trace-sample-rate must be in 0.0 -> 1.0")
+	}
+
+	return nil
+}
+
 func (j *jaeger) Configure() error {
 	j.logger = logger.GetCurrent().GetLogger(j.Name())
 	return nil
@@ -8,8 +8,10 @@ package oauthclient
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"github.com/200Lab-Education/go-sdk/util/secret"
 	"golang.org/x/oauth2/clientcredentials"
 	"net/http"
 )
@@ -52,11 +54,24 @@ func (o *oauth) Name() string {
 
 func (o *oauth) InitFlags() {
 	prefix := fmt.Sprintf("%s-", o.Name())
-	flag.StringVar(&o.clientConf.ClientSecret, prefix+"client-secret", o.clientConf.ClientSecret, "oauth client secret")
+	secret.StringVar(&o.clientConf.ClientSecret, prefix+"client-secret", o.clientConf.ClientSecret, "oauth client secret")
 	flag.StringVar(&o.clientConf.ClientID, prefix+"client-id", o.clientConf.ClientID, "oauth client id")
 	flag.StringVar(&o.clientConf.TokenURL, prefix+"token-url", o.clientConf.TokenURL, "oauth token url")
 }
 
+// Implement Validator interface
+func (o *oauth) Validate() error {
+	if o.clientConf.TokenURL == "" {
+		return nil
+	}
+
+	if o.clientConf.ClientID == "" || o.clientConf.ClientSecret == "" {
+		return errors.New("oauth client id and client secret are required")
+	}
+
+	return nil
+}
+
 func (o *oauth) Configure() error {
 	if o.clientConf.TokenURL == "" {
 		return nil
@@ -71,22 +71,14 @@ func (ps *pubsub) Run() error {
 }
 
 func (ps *pubsub) Stop() <-chan bool {
-	c := make(chan bool)
+	c := make(chan bool, 1)
 
 	go func() {
 		if ps.gracefulStop {
 			ps.wg.Wait()
 		}
 
-		ps.locker.Lock()
-
-		for _, chans := range ps.mapChannel {
-			for _, c := range chans {
-				close(c)
-			}
-		}
-		ps.mapChannel = make(map[pb.Channel][]chan *pb.Event)
-		ps.locker.Unlock()
+		ps.closeSubscribers()
 
 		if ps.logEnabled {
 			ps.logger.Infoln(fmt.Sprintf("Stopped"))
@@ -97,6 +89,25 @@ func (ps *pubsub) Stop() <-chan bool {
 	return c
 }
 
+// Implement ForceStopper interface, close subscribers without
+// waiting for unacked events
+func (ps *pubsub) ForceStop() error {
+	ps.closeSubscribers()
+	return nil
+}
+
+func (ps *pubsub) closeSubscribers() {
+	ps.locker.Lock()
+	defer ps.locker.Unlock()
+
+	for _, chans := range ps.mapChannel {
+		for _, c := range chans {
+			close(c)
+		}
+	}
+	ps.mapChannel = make(map[pb.Channel][]chan *pb.Event)
+}
+
 func (ps *pubsub) Publish(ctx context.Context, channel pb.Channel, data *pb.Event) error {
 	if ps.isStopping {
 		return nil
@@ -104,6 +115,8 @@ func (ps *pubsub) Publish(ctx context.Context, channel pb.Channel, data *pb.Even
 
 	// Need to know what channel event will push to
 	data.SetChannel(channel)
+	data.WithContext(ctx)
+	pb.PublishedEvents.WithLabelValues("local", string(channel)).Inc()
 
 	go func() {
 		ps.messageQueue <- data
@@ -180,6 +193,8 @@ func (ps *pubsub) listen() {
 						ps.wg.Add(1)
 					}
 
+					pb.ReceivedEvents.WithLabelValues("local", string(evt.GetChannel())).Add(float64(len(chans)))
+
 					for _, evtChan := range chans {
 						go func(c chan *pb.Event) { c <- evt }(evtChan)
 					}
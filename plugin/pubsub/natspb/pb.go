@@ -3,12 +3,21 @@ package natspb
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"github.com/200Lab-Education/go-sdk/logger"
 	pb "github.com/200Lab-Education/go-sdk/plugin/pubsub"
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/200Lab-Education/go-sdk/util/secret"
 	"github.com/nats-io/nats.go"
+	"sync"
+	"time"
 )
 
+// Interval to check whether draining connection is closed on Stop
+const drainCheckInterval = time.Millisecond * 50
+
 type NatsOpt struct {
 	prefix   string
 	server   string
@@ -17,11 +26,22 @@ type NatsOpt struct {
 	token    string
 }
 
+type subscription struct {
+	channel pb.Channel
+	handler nats.MsgHandler
+	sub     *nats.Subscription
+}
+
 type natspb struct {
 	name      string
 	logger    logger.Logger
 	nc        *nats.Conn
 	isRunning bool
+	// guards nc (swapped on reload) and subs
+	mu   *sync.RWMutex
+	subs map[*subscription]bool
+	// options of current connection, to detect changes on reload
+	connectedOpt NatsOpt
 	*NatsOpt
 }
 
@@ -32,6 +52,8 @@ func NewNatsPubSub(name string, prefix string) *natspb {
 			prefix: prefix,
 		},
 		isRunning: false,
+		mu:        new(sync.RWMutex),
+		subs:      map[*subscription]bool{},
 	}
 }
 
@@ -58,8 +80,8 @@ func (n *natspb) InitFlags() {
 
 	flag.StringVar(&n.server, prefix+"nats-server", "", "Nats connect server. Ex: \"nats://..., nats://\"")
 	flag.StringVar(&n.username, prefix+"nats-username", "", "Nats username")
-	flag.StringVar(&n.password, prefix+"nats-password", "", "Nats password")
-	flag.StringVar(&n.token, prefix+"nats-token", "", "Nats token")
+	secret.StringVar(&n.password, prefix+"nats-password", "", "Nats password")
+	secret.StringVar(&n.token, prefix+"nats-token", "", "Nats token")
 }
 
 func (n *natspb) Configure() error {
@@ -67,7 +89,31 @@ func (n *natspb) Configure() error {
 		return nil
 	}
 	n.logger = logger.GetCurrent().GetLogger(n.name)
-	n.logger.Info("Connecting to Nats at ", n.server, " ...")
+
+	nc, err := n.connect()
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	n.nc = nc
+	n.mu.Unlock()
+
+	n.connectedOpt = *n.NatsOpt
+	n.isRunning = true
+	return nil
+}
+
+// conn returns current connection, it is replaced on reload
+func (n *natspb) conn() *nats.Conn {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	return n.nc
+}
+
+func (n *natspb) connect() (*nats.Conn, error) {
+	n.logger.Info("Connecting to Nats at ", secret.MaskURI(n.server), " ...")
 
 	var options []nats.Option
 
@@ -89,15 +135,47 @@ func (n *natspb) Configure() error {
 		options = append(options, nats.Token(n.token))
 	}
 
-	nc, err := nats.Connect(n.server,
+	return nats.Connect(n.server,
 		options...)
+}
+
+// Implement Reloadable, connect again when server or credentials changed.
+// Subscriptions are moved to the new connection then the old one is drained
+func (n *natspb) Reload() error {
+	if !n.isRunning || n.connectedOpt == *n.NatsOpt {
+		return nil
+	}
 
+	nc, err := n.connect()
 	if err != nil {
 		return err
 	}
 
+	n.mu.Lock()
+	newSubs := make(map[*subscription]*nats.Subscription, len(n.subs))
+	for s := range n.subs {
+		sub, err := nc.Subscribe(string(s.channel), s.handler)
+		if err != nil {
+			n.mu.Unlock()
+			nc.Close()
+			return err
+		}
+		newSubs[s] = sub
+	}
+
+	for s, sub := range newSubs {
+		s.sub = sub
+	}
+
+	oldNc := n.nc
 	n.nc = nc
-	n.isRunning = true
+	n.connectedOpt = *n.NatsOpt
+	n.mu.Unlock()
+
+	if err := oldNc.Drain(); err != nil {
+		n.logger.Errorf("Error when drain nats connection: %q\n", err)
+	}
+
 	return nil
 }
 
@@ -105,21 +183,51 @@ func (n *natspb) Run() error {
 	return n.Configure()
 }
 
+// Stop drains the connection: pending messages are handled before it is closed
 func (n *natspb) Stop() <-chan bool {
-	if n.nc != nil {
-
-		err := n.nc.Drain()
+	nc := n.conn()
+	if nc != nil {
+		err := nc.Drain()
 		if err != nil {
 			n.logger.Errorf("Error when drain nats connection: %q\n", err)
 		}
 	}
 	n.isRunning = false
 
-	c := make(chan bool)
-	go func() { c <- true }()
+	c := make(chan bool, 1)
+	go func() {
+		for nc != nil && nc.IsDraining() {
+			time.Sleep(drainCheckInterval)
+		}
+		c <- true
+	}()
 	return c
 }
 
+// Implement ForceStopper interface, close the connection
+// without waiting for the drain to complete
+func (n *natspb) ForceStop() error {
+	if nc := n.conn(); nc != nil {
+		nc.Close()
+	}
+
+	return nil
+}
+
+// Implement HealthChecker interface
+func (n *natspb) HealthCheck(ctx context.Context) error {
+	nc := n.conn()
+	if nc == nil {
+		return errors.New("nats is not connected")
+	}
+
+	if status := nc.Status(); status != nats.CONNECTED {
+		return fmt.Errorf("nats connection status is %s", status)
+	}
+
+	return nil
+}
+
 func (n *natspb) Publish(ctx context.Context, channel pb.Channel, data *pb.Event) error {
 	dataByte, err := json.Marshal(data.Data)
 
@@ -128,11 +236,16 @@ func (n *natspb) Publish(ctx context.Context, channel pb.Channel, data *pb.Event
 		return err
 	}
 
-	if err := n.nc.Publish(string(channel), dataByte); err != nil {
+	nc := n.conn()
+	msg := newMsg(channel, dataByte, data.WithContext(ctx).RequestID, nc.HeadersSupported())
+
+	if err := nc.PublishMsg(msg); err != nil {
 		n.logger.Errorln(err)
 		return err
 	}
 
+	pb.PublishedEvents.WithLabelValues("nats", string(channel)).Inc()
+
 	//if err := n.nc.Flush(); err != nil {
 	//	n.logger.Errorln(err)
 	//	return err
@@ -144,21 +257,59 @@ func (n *natspb) Publish(ctx context.Context, channel pb.Channel, data *pb.Event
 func (n *natspb) Subscribe(ctx context.Context, channel pb.Channel) (c <-chan *pb.Event, cl func()) {
 	ch := make(chan *pb.Event)
 
-	sub, err := n.nc.Subscribe(string(channel), func(msg *nats.Msg) {
-		evt := &pb.Event{
-			Channel:    channel,
-			RemoteData: msg.Data,
-		}
-
-		ch <- evt
-	})
+	s := &subscription{
+		channel: channel,
+		handler: func(msg *nats.Msg) {
+			evt := eventFromMsg(channel, msg)
+			pb.ReceivedEvents.WithLabelValues("nats", string(channel)).Inc()
+			ch <- evt
+		},
+	}
 
+	n.mu.Lock()
+	sub, err := n.nc.Subscribe(string(channel), s.handler)
 	if err != nil {
 		n.logger.Errorln(err)
+	} else {
+		s.sub = sub
+		n.subs[s] = true
 	}
+	n.mu.Unlock()
 
 	return ch, func() {
-		_ = sub.Unsubscribe()
+		n.mu.Lock()
+		if s.sub != nil {
+			_ = s.sub.Unsubscribe()
+		}
+		delete(n.subs, s)
+		n.mu.Unlock()
+
 		close(ch)
 	}
 }
+
+// newMsg carries request ID in the message header,
+// headers need nats server 2.2+
+func newMsg(channel pb.Channel, data []byte, requestID string, headersSupported bool) *nats.Msg {
+	msg := nats.NewMsg(string(channel))
+	msg.Data = data
+
+	if requestID != "" && headersSupported {
+		msg.Header.Set(sdkcm.HeaderRequestID, requestID)
+	}
+
+	return msg
+}
+
+func eventFromMsg(channel pb.Channel, msg *nats.Msg) *pb.Event {
+	evt := &pb.Event{
+		Channel:    channel,
+		RemoteData: msg.Data,
+	}
+
+	if msg.Header != nil {
+		evt.RequestID = msg.Header.Get(sdkcm.HeaderRequestID)
+	}
+
+	return evt
+}
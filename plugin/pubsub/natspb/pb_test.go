@@ -0,0 +1,38 @@
+package natspb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/200Lab-Education/go-sdk/plugin/pubsub"
+	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadNotConnected(t *testing.T) {
+	n := NewNatsPubSub("nats", "")
+	n.server = "nats://localhost:4222"
+
+	// nothing to move before the first connection
+	assert.Nil(t, n.Reload(), "must be nil")
+	assert.Nil(t, n.conn(), "should not connect")
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	msg := newMsg("user.created", []byte(`{"id":1}`), "req-1", true)
+	assert.Equal(t, "req-1", msg.Header.Get(sdkcm.HeaderRequestID), "should be equal")
+
+	evt := eventFromMsg("user.created", msg)
+	assert.Equal(t, "req-1", evt.RequestID, "should be equal")
+	assert.Equal(t, pb.Channel("user.created"), evt.Channel, "should be equal")
+	assert.Equal(t, []byte(`{"id":1}`), evt.RemoteData, "should be equal")
+	assert.Equal(t, "req-1", sdkcm.RequestIDFromContext(evt.Context(context.Background())), "subscriber context should have request id")
+
+	// old servers reject messages with headers
+	msg = newMsg("user.created", nil, "req-1", false)
+	assert.Empty(t, msg.Header, "should not set headers")
+
+	evt = eventFromMsg("user.created", &nats.Msg{Subject: "user.created"})
+	assert.Equal(t, "", evt.RequestID, "should be empty")
+}
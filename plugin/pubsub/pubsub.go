@@ -4,10 +4,17 @@ import (
 	"context"
 	"fmt"
 	"github.com/200Lab-Education/go-sdk/sdkcm"
+	"github.com/200Lab-Education/go-sdk/util/metrics"
 	"github.com/globalsign/mgo/bson"
 	"time"
 )
 
+// Metrics of pubsub providers, labeled by provider name and channel
+var (
+	PublishedEvents = metrics.Default().Counter("pubsub_events_published_total", "Number of published events.", "provider", "channel")
+	ReceivedEvents  = metrics.Default().Counter("pubsub_events_received_total", "Number of events delivered to subscribers.", "provider", "channel")
+)
+
 type Channel string
 
 type Provider interface {
@@ -44,6 +51,8 @@ type Event struct {
 	Ack        func()
 	CreatedAt  time.Time `json:"created_at"`
 	RemoteData []byte    `json:"remote_data"`
+	// Correlates the event with the request publishing it
+	RequestID string `json:"request_id,omitempty"`
 }
 
 func (e Event) String() string {
@@ -82,6 +91,23 @@ func (e *Event) DoAck()                     { e.Ack() }
 func (e *Event) SetChannel(c Channel)       { e.Channel = c }
 func (e *Event) SetAck(f func())            { e.Ack = f }
 
+// WithContext sets request ID from ctx when the event has no one
+func (e *Event) WithContext(ctx context.Context) *Event {
+	if e.RequestID == "" {
+		e.RequestID = sdkcm.RequestIDFromContext(ctx)
+	}
+	return e
+}
+
+// Context returns ctx carrying request ID of the event,
+// subscribers use it to continue the same correlation
+func (e *Event) Context(ctx context.Context) context.Context {
+	if e.RequestID == "" {
+		return ctx
+	}
+	return sdkcm.ContextWithRequestID(ctx, e.RequestID)
+}
+
 func NewEvent(title string, author, receiver Entity, data interface{}) *Event {
 	return &Event{
 		Id:        bson.NewObjectId().Hex(),
@@ -7,8 +7,11 @@
 package sdkclickhouse
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/util/secret"
 	_ "github.com/ClickHouse/clickhouse-go"
 	"github.com/jmoiron/sqlx"
 	"sync"
@@ -29,6 +32,8 @@ type clickhouseDB struct {
 	session   *sqlx.DB
 	isRunning bool
 	once      *sync.Once
+	// guards isRunning, session and once, they are reset by the background ping
+	mu *sync.RWMutex
 	*CHDBOpt
 }
 
@@ -40,6 +45,7 @@ func NewClickHouseDB(name, prefix string) *clickhouseDB {
 		name:      name,
 		isRunning: false,
 		once:      new(sync.Once),
+		mu:        new(sync.RWMutex),
 	}
 }
 
@@ -57,7 +63,7 @@ func (chDB *clickhouseDB) InitFlags() {
 		prefix += "-"
 	}
 
-	flag.StringVar(&chDB.ChUri, prefix+"clickhouse-uri", "", "ClickHouse connection-string. Ex: tcp://host1:9000?username=user&password=qwerty&database=clicks")
+	secret.StringVar(&chDB.ChUri, prefix+"clickhouse-uri", "", "ClickHouse connection-string. Ex: tcp://host1:9000?username=user&password=qwerty&database=clicks")
 	flag.IntVar(&chDB.PingInterval, prefix+"clickhouse-ping-interval", 5, "ClickHouse ping check interval")
 }
 
@@ -66,30 +72,47 @@ func (chDB *clickhouseDB) isDisabled() bool {
 }
 
 func (chDB *clickhouseDB) Configure() error {
-	if chDB.isDisabled() || chDB.isRunning {
+	if chDB.isDisabled() || chDB.running() {
 		return nil
 	}
 
 	chDB.logger = logger.GetCurrent().GetLogger(chDB.name)
-	chDB.logger.Info("Connect to ClickHouse at ", chDB.ChUri, " ...")
+	chDB.logger.Info("Connect to ClickHouse at ", secret.MaskURI(chDB.ChUri), " ...")
 
-	var err error
-	chDB.session, err = chDB.getConnWithRetry()
+	session, err := chDB.getConnWithRetry()
 	if err != nil {
-		chDB.logger.Error("Error connect to ClickHouse at ", chDB.ChUri, ". ", err.Error())
+		chDB.logger.Error("Error connect to ClickHouse at ", secret.MaskURI(chDB.ChUri), ". ", err.Error())
 		return err
 	}
+
+	chDB.mu.Lock()
+	chDB.session = session
 	chDB.isRunning = true
+	chDB.mu.Unlock()
 	return nil
 }
 
+func (chDB *clickhouseDB) running() bool {
+	chDB.mu.RLock()
+	defer chDB.mu.RUnlock()
+
+	return chDB.isRunning
+}
+
+func (chDB *clickhouseDB) currentSession() *sqlx.DB {
+	chDB.mu.RLock()
+	defer chDB.mu.RUnlock()
+
+	return chDB.session
+}
+
 func (chDB *clickhouseDB) Cleanup() {
 	if chDB.isDisabled() {
 		return
 	}
 
-	if chDB.session != nil {
-		_ = chDB.session.Close()
+	if session := chDB.currentSession(); session != nil {
+		_ = session.Close()
 	}
 }
 
@@ -98,32 +121,59 @@ func (chDB *clickhouseDB) Run() error {
 }
 
 func (chDB *clickhouseDB) Stop() <-chan bool {
+	chDB.mu.Lock()
 	if chDB.session != nil {
 		_ = chDB.session.Close()
 	}
 	chDB.isRunning = false
+	chDB.mu.Unlock()
 
-	c := make(chan bool)
+	c := make(chan bool, 1)
 	go func() { c <- true }()
 	return c
 }
 
 func (chDB *clickhouseDB) Get() interface{} {
-	chDB.once.Do(func() {
-		if !chDB.isRunning && !chDB.isDisabled() {
+	chDB.mu.RLock()
+	once := chDB.once
+	chDB.mu.RUnlock()
+
+	once.Do(func() {
+		if !chDB.running() && !chDB.isDisabled() {
 			if db, err := chDB.getConnWithRetry(); err == nil {
+				chDB.mu.Lock()
 				chDB.session = db
 				chDB.isRunning = true
+				chDB.mu.Unlock()
 			} else {
 				chDB.logger.Fatalf("%s connection cannot reconnect\n", chDB.name)
 			}
 		}
 	})
 
-	if chDB.session == nil {
+	session := chDB.currentSession()
+	if session == nil {
 		return nil
 	}
-	return chDB.session
+	return session
+}
+
+// Implement HealthChecker interface,
+// the connection is marked as gone by the background ping
+func (chDB *clickhouseDB) HealthCheck(ctx context.Context) error {
+	if chDB.isDisabled() {
+		return nil
+	}
+
+	chDB.mu.RLock()
+	isRunning, session := chDB.isRunning, chDB.session
+	chDB.mu.RUnlock()
+
+	if !isRunning || session == nil {
+		return errors.New("clickhouse is not connected")
+	}
+
+	return session.PingContext(ctx)
 }
 
 func (chDB *clickhouseDB) getConnWithRetry() (*sqlx.DB, error) {
@@ -136,25 +186,32 @@ func (chDB *clickhouseDB) getConnWithRetry() (*sqlx.DB, error) {
 			db, err = sqlx.Connect("clickhouse", chDB.ChUri)
 
 			if err == nil {
-				go chDB.reconnectIfNeeded()
+				go chDB.reconnectIfNeeded(db)
 				break
 			}
 		}
 	} else {
-		go chDB.reconnectIfNeeded()
+		go chDB.reconnectIfNeeded(db)
 	}
 
 	return db, err
 }
 
-func (chDB *clickhouseDB) reconnectIfNeeded() {
-	conn := chDB.session
+func (chDB *clickhouseDB) reconnectIfNeeded(conn *sqlx.DB) {
 	for {
+		// stopped, the session is closed
+		if chDB.currentSession() == conn && !chDB.running() {
+			return
+		}
+
 		if err := conn.Ping(); err != nil {
 			_ = conn.Close()
 			chDB.logger.Errorf("%s connection is gone, try to reconnect\n", chDB.name)
+
+			chDB.mu.Lock()
 			chDB.isRunning = false
 			chDB.once = new(sync.Once)
+			chDB.mu.Unlock()
 
 			_ = chDB.Get().(*sqlx.DB).Close()
 			return
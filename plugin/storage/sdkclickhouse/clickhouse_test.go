@@ -0,0 +1,37 @@
+package sdkclickhouse
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentStopAndHealthCheck(t *testing.T) {
+	gdb := NewClickHouseDB("test", "")
+	gdb.ChUri = "tcp://localhost:9000"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			<-gdb.Stop()
+		}()
+		go func() {
+			defer wg.Done()
+			_ = gdb.HealthCheck(context.Background())
+		}()
+		go func() {
+			defer wg.Done()
+			gdb.mu.Lock()
+			gdb.isRunning = true
+			gdb.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	<-gdb.Stop()
+	assert.NotNil(t, gdb.HealthCheck(context.Background()), "stopped database must be unhealthy")
+}
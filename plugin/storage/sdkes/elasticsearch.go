@@ -11,6 +11,7 @@ package sdkes
 import (
 	"flag"
 	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/util/secret"
 	"github.com/olivere/elastic/v7"
 	"log"
 	"os"
@@ -72,7 +73,7 @@ func (es *es) InitFlags() {
 	flag.BoolVar(&es.HasSniff, prefix+"es-has-sniff", false, "elastic search sniffing mode. default: false")
 	flag.BoolVar(&es.HasHealthCheck, prefix+"es-has-health-check", false, "elastic search health-check mode. default: false")
 	flag.StringVar(&es.Username, prefix+"es-username", "", "elasticsearch username")
-	flag.StringVar(&es.Password, prefix+"es-password", "", "elasticsearch password")
+	secret.StringVar(&es.Password, prefix+"es-password", "", "elasticsearch password")
 	flag.Parse()
 }
 
@@ -81,7 +82,7 @@ func (es *es) Configure() error {
 		return nil
 	}
 	es.logger = logger.GetCurrent().GetLogger(es.name)
-	es.logger.Info("connecting to elastic search at ", es.URL, "...")
+	es.logger.Info("connecting to elastic search at ", secret.MaskURI(es.URL), "...")
 
 	var client *elastic.Client
 	var err error
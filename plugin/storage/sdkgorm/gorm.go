@@ -7,13 +7,17 @@
 package sdkgorm
 
 import (
+	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"github.com/200Lab-Education/go-sdk/logger"
 	"github.com/200Lab-Education/go-sdk/plugin/storage/sdkgorm/gormdialects"
+	"github.com/200Lab-Education/go-sdk/util/secret"
 	"gorm.io/gorm"
 	"strings"
 	"sync"
+	"time"
 )
 
 type GormDBType int
@@ -41,6 +45,9 @@ type gormDB struct {
 	db        *gorm.DB
 	isRunning bool
 	once      *sync.Once
+	mu        *sync.RWMutex
+	pingErr   error
+	stopChan  chan bool
 	*GormOpt
 }
 
@@ -52,6 +59,7 @@ func NewGormDB(name, prefix string) *gormDB {
 		name:      name,
 		isRunning: false,
 		once:      new(sync.Once),
+		mu:        new(sync.RWMutex),
 	}
 }
 
@@ -69,7 +77,7 @@ func (gdb *gormDB) InitFlags() {
 		prefix += "-"
 	}
 
-	flag.StringVar(&gdb.Uri, prefix+"gorm-db-uri", "", "Gorm database connection-string.")
+	secret.StringVar(&gdb.Uri, prefix+"gorm-db-uri", "", "Gorm database connection-string.")
 	flag.StringVar(&gdb.DBType, prefix+"gorm-db-type", "", "Gorm database type (mysql, postgres, sqlite, mssql)")
 	flag.IntVar(&gdb.PingInterval, prefix+"gorm-db-ping-interval", 5, "Gorm database ping check interval")
 }
@@ -78,7 +86,23 @@ func (gdb *gormDB) isDisabled() bool {
 	return gdb.Uri == ""
 }
 
+// Implement Validator interface
+func (gdb *gormDB) Validate() error {
+	if gdb.isDisabled() {
+		return nil
+	}
+
+	if getDBType(gdb.DBType) == GormDBTypeNotSupported {
+		return fmt.Errorf("gorm database type %q is not supported, use: mysql | postgres | sqlite | mssql", gdb.DBType)
+	}
+
+	return nil
+}
+
 func (gdb *gormDB) Configure() error {
+	gdb.mu.Lock()
+	defer gdb.mu.Unlock()
+
 	if gdb.isDisabled() || gdb.isRunning {
 		return nil
 	}
@@ -90,15 +114,18 @@ func (gdb *gormDB) Configure() error {
 		return errors.New("gorm database type is not supported")
 	}
 
-	gdb.logger.Info("Connect to Gorm DB at ", gdb.Uri, " ...")
+	gdb.logger.Info("Connect to Gorm DB at ", secret.MaskURI(gdb.Uri), " ...")
 
 	var err error
 	gdb.db, err = gdb.getDBConn(dbType)
 	if err != nil {
-		gdb.logger.Error("Error connect to gorm database at ", gdb.Uri, ". ", err.Error())
+		gdb.logger.Error("Error connect to gorm database at ", secret.MaskURI(gdb.Uri), ". ", err.Error())
 		return err
 	}
 	gdb.isRunning = true
+	gdb.stopChan = make(chan bool)
+
+	go gdb.pingIfNeeded(gdb.stopChan)
 
 	return nil
 }
@@ -108,9 +135,14 @@ func (gdb *gormDB) Run() error {
 }
 
 func (gdb *gormDB) Stop() <-chan bool {
+	gdb.mu.Lock()
+	if gdb.isRunning {
+		close(gdb.stopChan)
+	}
 	gdb.isRunning = false
+	gdb.mu.Unlock()
 
-	c := make(chan bool)
+	c := make(chan bool, 1)
 	go func() {
 		c <- true
 		gdb.logger.Infoln("Stopped")
@@ -118,6 +150,25 @@ func (gdb *gormDB) Stop() <-chan bool {
 	return c
 }
 
+// Implement ForceStopper interface, close all connections of the pool
+// including the ones in use by queries still running
+func (gdb *gormDB) ForceStop() error {
+	gdb.mu.RLock()
+	db := gdb.db
+	gdb.mu.RUnlock()
+
+	if db == nil {
+		return nil
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
+}
+
 func (gdb *gormDB) Get() interface{} {
 	if gdb.logger.GetLevel() == "debug" || gdb.logger.GetLevel() == "trace" {
 		return gdb.db.Session(&gorm.Session{NewDB: true}).Debug()
@@ -125,6 +176,63 @@ func (gdb *gormDB) Get() interface{} {
 	return gdb.db.Session(&gorm.Session{NewDB: true})
 }
 
+// Ping database every PingInterval seconds,
+// the latest result is reported by HealthCheck
+func (gdb *gormDB) pingIfNeeded(stopChan chan bool) {
+	interval := time.Second * time.Duration(gdb.PingInterval)
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := gdb.ping(ctx)
+			cancel()
+
+			gdb.mu.Lock()
+			if err != nil && gdb.pingErr == nil {
+				gdb.logger.Errorf("%s connection is gone: %s\n", gdb.name, err.Error())
+			} else if err == nil && gdb.pingErr != nil {
+				gdb.logger.Infof("%s connection is back\n", gdb.name)
+			}
+			gdb.pingErr = err
+			gdb.mu.Unlock()
+		}
+	}
+}
+
+func (gdb *gormDB) ping(ctx context.Context) error {
+	sqlDB, err := gdb.db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
+
+// Implement HealthChecker interface
+func (gdb *gormDB) HealthCheck(ctx context.Context) error {
+	if gdb.isDisabled() {
+		return nil
+	}
+
+	gdb.mu.RLock()
+	defer gdb.mu.RUnlock()
+
+	if !gdb.isRunning {
+		return errors.New("gorm database is not connected")
+	}
+
+	return gdb.pingErr
+}
+
 func getDBType(dbType string) GormDBType {
 	switch strings.ToLower(dbType) {
 	case "mysql":
@@ -0,0 +1,36 @@
+package sdkgorm
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentStopAndHealthCheck(t *testing.T) {
+	logger.InitServLogger(false)
+
+	gdb := NewGormDB("test", "")
+	gdb.Uri = "sqlite://test.db"
+	gdb.logger = logger.GetCurrent().GetLogger("test")
+	gdb.isRunning = true
+	gdb.stopChan = make(chan bool)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-gdb.Stop()
+		}()
+		go func() {
+			defer wg.Done()
+			_ = gdb.HealthCheck(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	assert.NotNil(t, gdb.HealthCheck(context.Background()), "stopped database must be unhealthy")
+}
@@ -7,8 +7,11 @@
 package sdkmgo
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/util/secret"
 	"math"
 	"sync"
 	"time"
@@ -35,6 +38,8 @@ type mongoDB struct {
 	session   *mgo.Session
 	isRunning bool
 	once      *sync.Once
+	// guards isRunning, session and once, they are reset by the background ping
+	mu *sync.RWMutex
 	*MongoDBOpt
 }
 
@@ -50,6 +55,7 @@ func NewMongoDB(name, prefix string) *mongoDB {
 		name:      name,
 		isRunning: false,
 		once:      new(sync.Once),
+		mu:        new(sync.RWMutex),
 	}
 }
 
@@ -67,7 +73,7 @@ func (mgDB *mongoDB) InitFlags() {
 		prefix += "-"
 	}
 
-	flag.StringVar(&mgDB.MgoUri, prefix+"mgo-uri", "", "MongoDB connection-string. Ex: mongodb://...")
+	secret.StringVar(&mgDB.MgoUri, prefix+"mgo-uri", "", "MongoDB connection-string. Ex: mongodb://...")
 	flag.IntVar(&mgDB.PingInterval, prefix+"mgo-ping-interval", 5, "MongoDB ping check interval")
 }
 
@@ -76,30 +82,47 @@ func (mgDB *mongoDB) isDisabled() bool {
 }
 
 func (mgDB *mongoDB) Configure() error {
-	if mgDB.isDisabled() || mgDB.isRunning {
+	if mgDB.isDisabled() || mgDB.running() {
 		return nil
 	}
 
 	mgDB.logger = logger.GetCurrent().GetLogger(mgDB.name)
-	mgDB.logger.Info("Connect to Mongodb at ", mgDB.MgoUri, " ...")
+	mgDB.logger.Info("Connect to Mongodb at ", secret.MaskURI(mgDB.MgoUri), " ...")
 
-	var err error
-	mgDB.session, err = mgDB.getConnWithRetry(retryCount)
+	session, err := mgDB.getConnWithRetry(retryCount)
 	if err != nil {
-		mgDB.logger.Error("Error connect to mongodb at ", mgDB.MgoUri, ". ", err.Error())
+		mgDB.logger.Error("Error connect to mongodb at ", secret.MaskURI(mgDB.MgoUri), ". ", err.Error())
 		return err
 	}
+
+	mgDB.mu.Lock()
+	mgDB.session = session
 	mgDB.isRunning = true
+	mgDB.mu.Unlock()
 	return nil
 }
 
+func (mgDB *mongoDB) running() bool {
+	mgDB.mu.RLock()
+	defer mgDB.mu.RUnlock()
+
+	return mgDB.isRunning
+}
+
+func (mgDB *mongoDB) currentSession() *mgo.Session {
+	mgDB.mu.RLock()
+	defer mgDB.mu.RUnlock()
+
+	return mgDB.session
+}
+
 func (mgDB *mongoDB) Cleanup() {
 	if mgDB.isDisabled() {
 		return
 	}
 
-	if mgDB.session != nil {
-		mgDB.session.Close()
+	if session := mgDB.currentSession(); session != nil {
+		session.Close()
 	}
 }
 
@@ -108,32 +131,72 @@ func (mgDB *mongoDB) Run() error {
 }
 
 func (mgDB *mongoDB) Stop() <-chan bool {
+	mgDB.mu.Lock()
 	if mgDB.session != nil {
 		mgDB.session.Close()
 	}
 	mgDB.isRunning = false
+	mgDB.mu.Unlock()
 
-	c := make(chan bool)
+	c := make(chan bool, 1)
 	go func() { c <- true }()
 	return c
 }
 
 func (mgDB *mongoDB) Get() interface{} {
-	mgDB.once.Do(func() {
-		if !mgDB.isRunning && !mgDB.isDisabled() {
+	mgDB.mu.RLock()
+	once := mgDB.once
+	mgDB.mu.RUnlock()
+
+	once.Do(func() {
+		if !mgDB.running() && !mgDB.isDisabled() {
 			if db, err := mgDB.getConnWithRetry(math.MaxInt32); err == nil {
+				mgDB.mu.Lock()
 				mgDB.session = db
 				mgDB.isRunning = true
+				mgDB.mu.Unlock()
 			} else {
 				mgDB.logger.Fatalf("%s connection cannot reconnect\n", mgDB.name)
 			}
 		}
 	})
 
-	if mgDB.session == nil {
+	session := mgDB.currentSession()
+	if session == nil {
+		return nil
+	}
+	return session.New()
+}
+
+// Implement HealthChecker interface, it pings the server
+// so a dropped connection is reported before the background ping sees it
+func (mgDB *mongoDB) HealthCheck(ctx context.Context) error {
+	if mgDB.isDisabled() {
 		return nil
 	}
-	return mgDB.session.New()
+
+	mgDB.mu.RLock()
+	isRunning, session := mgDB.isRunning, mgDB.session
+	mgDB.mu.RUnlock()
+
+	if !isRunning || session == nil {
+		return errors.New("mongodb is not connected")
+	}
+
+	// mgo has no context, ping in background to respect ctx deadline
+	s := session.Copy()
+	result := make(chan error, 1)
+	go func() {
+		defer s.Close()
+		result <- s.Ping()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (mgDB *mongoDB) getConnWithRetry(retryCount int) (*mgo.Session, error) {
@@ -146,25 +209,32 @@ func (mgDB *mongoDB) getConnWithRetry(retryCount int) (*mgo.Session, error) {
 			db, err = mgo.Dial(mgDB.MgoUri)
 
 			if err == nil {
-				go mgDB.reconnectIfNeeded()
+				go mgDB.reconnectIfNeeded(db)
 				break
 			}
 		}
 	} else {
-		go mgDB.reconnectIfNeeded()
+		go mgDB.reconnectIfNeeded(db)
 	}
 
 	return db, err
 }
 
-func (mgDB *mongoDB) reconnectIfNeeded() {
-	conn := mgDB.session
+func (mgDB *mongoDB) reconnectIfNeeded(conn *mgo.Session) {
 	for {
+		// stopped, the session is closed
+		if mgDB.currentSession() == conn && !mgDB.running() {
+			return
+		}
+
 		if err := conn.Ping(); err != nil {
 			conn.Close()
 			mgDB.logger.Errorf("%s connection is gone, try to reconnect\n", mgDB.name)
+
+			mgDB.mu.Lock()
 			mgDB.isRunning = false
 			mgDB.once = new(sync.Once)
+			mgDB.mu.Unlock()
 
 			mgDB.Get().(*mgo.Session).Close()
 			return
@@ -0,0 +1,37 @@
+package sdkmgo
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentStopAndHealthCheck(t *testing.T) {
+	gdb := NewMongoDB("test", "")
+	gdb.MgoUri = "mongodb://localhost"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			<-gdb.Stop()
+		}()
+		go func() {
+			defer wg.Done()
+			_ = gdb.HealthCheck(context.Background())
+		}()
+		go func() {
+			defer wg.Done()
+			gdb.mu.Lock()
+			gdb.isRunning = true
+			gdb.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	<-gdb.Stop()
+	assert.NotNil(t, gdb.HealthCheck(context.Background()), "stopped database must be unhealthy")
+}
@@ -27,9 +27,13 @@ package sdkredis
 // 		Distributed Locks.
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/200Lab-Education/go-sdk/util/secret"
 	"github.com/go-redis/redis/v7"
+	"sync"
 )
 
 var (
@@ -46,10 +50,22 @@ type RedisDBOpt struct {
 	MaxIde    int
 }
 
+// Credentials used by new connections of the pool
+type redisAuth struct {
+	username string
+	password string
+}
+
 type redisDB struct {
 	name   string
 	client *redis.Client
 	logger logger.Logger
+	// guards auth, it is rotated on reload
+	mu   *sync.RWMutex
+	auth redisAuth
+	// options of current connection, to detect changes on reload
+	connectedOpt RedisDBOpt
+	connectedURL *redis.Options
 	*RedisDBOpt
 }
 
@@ -60,6 +76,7 @@ func getDefaultRedisDB() *redisDB {
 func NewRedisDB(name, flagPrefix string) *redisDB {
 	return &redisDB{
 		name: name,
+		mu:   new(sync.RWMutex),
 		RedisDBOpt: &RedisDBOpt{
 			Prefix:    flagPrefix,
 			MaxActive: defaultRedisMaxActive,
@@ -82,18 +99,28 @@ func (r *redisDB) InitFlags() {
 		prefix += "-"
 	}
 
-	flag.StringVar(&r.RedisUri, prefix+"go-redis-uri", "", "(For go-redis) Redis connection-string. Ex: redis://localhost/0")
+	secret.StringVar(&r.RedisUri, prefix+"go-redis-uri", "", "(For go-redis) Redis connection-string. Ex: redis://localhost/0")
 	flag.IntVar(&r.MaxActive, prefix+"go-redis-pool-max-active", defaultRedisMaxActive, "(For go-redis) Override redis pool MaxActive")
 	flag.IntVar(&r.MaxIde, prefix+"go-redis-pool-max-idle", defaultRedisMaxIdle, "(For go-redis) Override redis pool MaxIdle")
 }
 
+// Implement Validator interface
+func (r *redisDB) Validate() error {
+	if r.isDisabled() {
+		return nil
+	}
+
+	_, err := redis.ParseURL(r.RedisUri)
+	return err
+}
+
 func (r *redisDB) Configure() error {
 	if r.isDisabled() {
 		return nil
 	}
 
 	r.logger = logger.GetCurrent().GetLogger(r.name)
-	r.logger.Info("Connecting to Redis at ", r.RedisUri, "...")
+	r.logger.Info("Connecting to Redis at ", secret.MaskURI(r.RedisUri), "...")
 
 	opt, err := redis.ParseURL(r.RedisUri)
 
@@ -102,10 +129,10 @@ func (r *redisDB) Configure() error {
 		return err
 	}
 
-	opt.PoolSize = r.MaxActive
-	opt.MinIdleConns = r.MaxIde
+	r.connectedURL = opt
+	r.setAuth(opt)
 
-	client := redis.NewClient(opt)
+	client := redis.NewClient(r.clientOptions(opt))
 
 	// Ping to test Redis connection
 	if err := client.Ping().Err(); err != nil {
@@ -115,9 +142,56 @@ func (r *redisDB) Configure() error {
 
 	// Connect successfully, assign client to goRedisDB
 	r.client = client
+	r.connectedOpt = *r.RedisDBOpt
 	return nil
 }
 
+// clientOptions authenticates new connections with the current credentials
+// in OnConnect instead of opt.Password, so they can be rotated on reload
+func (r *redisDB) clientOptions(parsed *redis.Options) *redis.Options {
+	opt := *parsed
+	opt.PoolSize = r.MaxActive
+	opt.MinIdleConns = r.MaxIde
+
+	// go-redis selects DB before OnConnect, it must be done after AUTH
+	db := opt.DB
+	opt.Username, opt.Password, opt.DB = "", "", 0
+
+	opt.OnConnect = func(conn *redis.Conn) error {
+		r.mu.RLock()
+		auth := r.auth
+		r.mu.RUnlock()
+
+		if auth.password != "" {
+			var err error
+			if auth.username != "" {
+				err = conn.AuthACL(auth.username, auth.password).Err()
+			} else {
+				err = conn.Auth(auth.password).Err()
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		if db > 0 {
+			return conn.Select(db).Err()
+		}
+
+		return nil
+	}
+
+	return &opt
+}
+
+func (r *redisDB) setAuth(opt *redis.Options) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.auth = redisAuth{username: opt.Username, password: opt.Password}
+}
+
 func (r *redisDB) Name() string {
 	return r.name
 }
@@ -126,10 +200,64 @@ func (r *redisDB) Get() interface{} {
 	return r.client
 }
 
+// Implement HealthChecker interface
+func (r *redisDB) HealthCheck(ctx context.Context) error {
+	if r.isDisabled() {
+		return nil
+	}
+
+	if r.client == nil {
+		return errors.New("redis is not connected")
+	}
+
+	return r.client.WithContext(ctx).Ping().Err()
+}
+
 func (r *redisDB) Run() error {
 	return r.Configure()
 }
 
+// Implement Reloadable. Consumers keep the client returned by Get,
+// so it is never replaced: new credentials are checked then used by
+// new connections of the pool, opened connections stay authenticated.
+// Changed address, database or pool options need a restart
+func (r *redisDB) Reload() error {
+	if r.isDisabled() || r.connectedOpt == *r.RedisDBOpt {
+		return nil
+	}
+
+	if r.client == nil {
+		return errors.New("redis is not connected")
+	}
+
+	opt, err := redis.ParseURL(r.RedisUri)
+	if err != nil {
+		return err
+	}
+
+	if opt.Addr != r.connectedURL.Addr || opt.DB != r.connectedURL.DB ||
+		r.MaxActive != r.connectedOpt.MaxActive || r.MaxIde != r.connectedOpt.MaxIde {
+		return errors.New("redis address, database or pool options are changed, restart the service to apply them")
+	}
+
+	// check new credentials before new connections use them
+	checkOpt := *opt
+	checkOpt.PoolSize = 1
+	check := redis.NewClient(&checkOpt)
+	defer check.Close()
+
+	if err := check.Ping().Err(); err != nil {
+		return err
+	}
+
+	r.setAuth(opt)
+	r.connectedURL = opt
+	r.connectedOpt = *r.RedisDBOpt
+	r.logger.Infoln("redis credentials are rotated")
+
+	return nil
+}
+
 func (r *redisDB) Stop() <-chan bool {
 	if r.client != nil {
 		if err := r.client.Close(); err != nil {
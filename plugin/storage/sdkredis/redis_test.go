@@ -0,0 +1,183 @@
+package sdkredis
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedis answers AUTH, SELECT and PING, it accepts only its current password
+type fakeRedis struct {
+	lis      net.Listener
+	mu       sync.Mutex
+	password string
+	conns    []net.Conn
+	selected []string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err, "must be nil")
+
+	f := &fakeRedis{lis: lis, password: password}
+	t.Cleanup(func() {
+		_ = lis.Close()
+		f.dropConns()
+	})
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+
+			f.mu.Lock()
+			f.conns = append(f.conns, conn)
+			f.mu.Unlock()
+
+			go f.serve(conn)
+		}
+	}()
+
+	return f
+}
+
+func (f *fakeRedis) uri(password string, db int) string {
+	return fmt.Sprintf("redis://:%s@%s/%d", password, f.lis.Addr().String(), db)
+}
+
+func (f *fakeRedis) setPassword(password string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.password = password
+}
+
+func (f *fakeRedis) dropConns() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, c := range f.conns {
+		_ = c.Close()
+	}
+	f.conns = nil
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	authed := false
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		f.mu.Lock()
+		reply := "+OK"
+		switch strings.ToUpper(args[0]) {
+		case "AUTH":
+			if authed = args[len(args)-1] == f.password; !authed {
+				reply = "-WRONGPASS invalid password"
+			}
+		case "SELECT":
+			if authed {
+				f.selected = append(f.selected, args[1])
+			} else {
+				reply = "-NOAUTH Authentication required."
+			}
+		case "PING":
+			if reply = "+PONG"; !authed {
+				reply = "-NOAUTH Authentication required."
+			}
+		default:
+			reply = "-ERR unknown command"
+		}
+		f.mu.Unlock()
+
+		if _, err := conn.Write([]byte(reply + "\r\n")); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSpace(arg)
+	}
+
+	return args, nil
+}
+
+func newTestRedis(t *testing.T, uri string) *redisDB {
+	logger.InitServLogger(false)
+
+	r := NewRedisDB("test", "")
+	r.RedisUri = uri
+	assert.Nil(t, r.Configure(), "must be nil")
+	t.Cleanup(func() { <-r.Stop() })
+
+	return r
+}
+
+func TestReloadRotatesCredentials(t *testing.T) {
+	server := newFakeRedis(t, "old")
+	r := newTestRedis(t, server.uri("old", 2))
+	client := r.Get()
+
+	server.setPassword("new")
+
+	r.RedisUri = server.uri("wrong", 2)
+	assert.NotNil(t, r.Reload(), "wrong credentials must be rejected")
+
+	r.RedisUri = server.uri("new", 2)
+	assert.Nil(t, r.Reload(), "must be nil")
+	assert.Equal(t, client, r.Get(), "client should not be replaced")
+
+	// new connections authenticate with the rotated password
+	server.dropConns()
+	assert.Eventually(t, func() bool { return r.client.Ping().Err() == nil }, time.Second, 10*time.Millisecond, "should reconnect with new password")
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, "2", server.selected[len(server.selected)-1], "database should be selected after AUTH")
+}
+
+func TestReloadNeedsRestart(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	r := newTestRedis(t, server.uri("secret", 0))
+
+	r.RedisUri = server.uri("secret", 3)
+	assert.NotNil(t, r.Reload(), "changed database needs a restart")
+
+	r.RedisUri = server.uri("secret", 0)
+	r.MaxActive = 50
+	assert.NotNil(t, r.Reload(), "changed pool options need a restart")
+}
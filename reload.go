@@ -0,0 +1,92 @@
+// Copyright (c) 2019, Viet Tran, 200Lab Team.
+
+package goservice
+
+import (
+	"os"
+	"strings"
+
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/joho/godotenv"
+)
+
+// reload re-reads env file and flags then reloads every component
+// implementing Reloadable. It is triggered by SIGHUP
+func (s *service) reload() {
+	s.logger.Infoln("Reloading config...")
+
+	if err := s.reloadEnvFile(); err != nil {
+		s.logger.Errorf("Reloading env(%s): %s", s.envFile, err.Error())
+		return
+	}
+
+	if err := s.cmdLine.Parse([]string{}); err != nil {
+		s.logger.Errorf("Parsing flags: %s", err.Error())
+		return
+	}
+
+	if r, ok := logger.GetCurrent().(Reloadable); ok {
+		s.reloadComponent("logger", r)
+	}
+
+	for _, subService := range s.subServices {
+		if r, ok := subService.(Reloadable); ok {
+			s.reloadComponent(subService.Name(), r)
+		}
+	}
+
+	for _, prefix := range s.initPrefixes {
+		if r, ok := s.initServices[prefix].(Reloadable); ok {
+			s.reloadComponent(s.initServices[prefix].Name(), r)
+		}
+	}
+
+	s.logger.Infoln("config reloaded")
+}
+
+func (s *service) reloadComponent(name string, r Reloadable) {
+	if err := r.Reload(); err != nil {
+		s.logger.Errorf("cannot reload %s: %s", name, err.Error())
+		return
+	}
+
+	s.logger.Infof("%s reloaded", name)
+}
+
+// Values of env file never override variables set by the process environment,
+// the same way as they are loaded at startup
+func (s *service) reloadEnvFile() error {
+	if _, err := os.Stat(s.envFile); err != nil {
+		if s.envFile == ".env" {
+			return nil
+		}
+		return err
+	}
+
+	envs, err := godotenv.Read(s.envFile)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range envs {
+		if _, ok := s.processEnv[k]; ok {
+			continue
+		}
+
+		if err := os.Setenv(k, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getProcessEnv() map[string]bool {
+	envs := map[string]bool{}
+
+	for _, kv := range os.Environ() {
+		envs[strings.SplitN(kv, "=", 2)[0]] = true
+	}
+
+	return envs
+}
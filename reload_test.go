@@ -0,0 +1,97 @@
+package goservice
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRunnable struct {
+	name string
+}
+
+func (m *mockRunnable) Name() string     { return m.name }
+func (m *mockRunnable) InitFlags()       {}
+func (m *mockRunnable) Configure() error { return nil }
+func (m *mockRunnable) Run() error       { return nil }
+func (m *mockRunnable) Stop() <-chan bool {
+	c := make(chan bool, 1)
+	c <- true
+	return c
+}
+
+type mockReloadable struct {
+	mockRunnable
+	reloads int
+	err     error
+}
+
+func (m *mockReloadable) Reload() error {
+	m.reloads++
+	return m.err
+}
+
+func newReloadTestService(t *testing.T, envFile string, fs *flag.FlagSet, components ...Runnable) *service {
+	logger.InitServLogger(false)
+
+	return &service{
+		cmdLine:     newFlagSet("test", fs),
+		envFile:     envFile,
+		processEnv:  getProcessEnv(),
+		subServices: components,
+		logger:      logger.GetCurrent().GetLogger("test"),
+	}
+}
+
+func TestReload(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	assert.Nil(t, ioutil.WriteFile(envFile, []byte("RELOAD_TEST_LEVEL=info\n"), 0644), "must be nil")
+	t.Cleanup(func() { _ = os.Unsetenv("RELOAD_TEST_LEVEL") })
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	level := fs.String("reload-test-level", "debug", "")
+
+	ok := &mockReloadable{mockRunnable: mockRunnable{name: "ok"}}
+	failed := &mockReloadable{mockRunnable: mockRunnable{name: "failed"}, err: errors.New("cannot reconnect")}
+	s := newReloadTestService(t, envFile, fs, failed, ok)
+
+	assert.Nil(t, s.reloadEnvFile(), "must be nil")
+	assert.Nil(t, s.cmdLine.Parse(nil), "must be nil")
+	assert.Equal(t, "info", *level, "should be set by env file")
+
+	assert.Nil(t, ioutil.WriteFile(envFile, []byte("RELOAD_TEST_LEVEL=warn\n"), 0644), "must be nil")
+	s.reload()
+
+	assert.Equal(t, "warn", *level, "should be reloaded from env file")
+	assert.Equal(t, 1, failed.reloads, "should be equal")
+	assert.Equal(t, 1, ok.reloads, "a failed component should not stop the others")
+}
+
+func TestReloadKeepsProcessEnv(t *testing.T) {
+	t.Setenv("RELOAD_TEST_ADDR", "from-process")
+
+	envFile := filepath.Join(t.TempDir(), ".env")
+	assert.Nil(t, ioutil.WriteFile(envFile, []byte("RELOAD_TEST_ADDR=from-file\n"), 0644), "must be nil")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	addr := fs.String("reload-test-addr", "", "")
+	s := newReloadTestService(t, envFile, fs)
+
+	s.reload()
+	assert.Equal(t, "from-process", *addr, "process env should win over env file")
+}
+
+func TestReloadMissingEnvFile(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	c := &mockReloadable{mockRunnable: mockRunnable{name: "c"}}
+	s := newReloadTestService(t, filepath.Join(t.TempDir(), "prod.env"), fs, c)
+
+	s.reload()
+	assert.Equal(t, 0, c.reloads, "components should not reload when env file is missing")
+}
@@ -1,7 +1,9 @@
 package sdkcm
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 )
 
@@ -52,34 +54,34 @@ var (
 
 var (
 	ErrCannotFetchData = func(err error) AppError {
-		return NewAppErr(err, http.StatusBadRequest, "can not fetch data").WithCode("cannot_fetch_data")
+		return newAppErr(err, defCannotFetchData)
 	}
 	ErrDB = func(err error) AppError {
-		return NewAppErr(err, http.StatusBadRequest, "db error").WithCode("db_error")
+		return newAppErr(err, defDB)
 	}
 	ErrInvalidRequest = func(err error) AppError {
-		return NewAppErr(err, http.StatusBadRequest, "invalid request").WithCode("invalid_request")
+		return newAppErr(err, defInvalidRequest)
 	}
 	ErrInvalidRequestWithMessage = func(err error, message string) AppError {
-		return NewAppErr(err, http.StatusBadRequest, message).WithCode("invalid_request")
+		return newAppErrAt(err, http.StatusBadRequest, message, 1).WithCode(defInvalidRequest.Key)
 	}
 	ErrWithMessage = func(root error, err ErrorWithKey) AppError {
-		if root == nil {
-			return NewAppErr(errors.New(err.Error()), http.StatusBadRequest, err.Error()).WithCode(err.Key())
-		}
-		return NewAppErr(root, http.StatusBadRequest, err.Error()).WithCode(err.Key())
+		return newKeyedAppErr(root, err, statusCodeOf(err, http.StatusBadRequest))
 	}
 	ErrCustom = func(root error, err ErrorWithKey) AppError {
-		if root == nil {
-			return NewAppErr(errors.New(err.Error()), http.StatusBadRequest, err.Error()).WithCode(err.Key())
-		}
-		return NewAppErr(root, http.StatusBadRequest, err.Error()).WithCode(err.Key())
+		return newKeyedAppErr(root, err, statusCodeOf(err, http.StatusBadRequest))
+	}
+	ErrRequestTooLarge = func(limit int64) AppError {
+		return newAppErr(fmt.Errorf("request body exceeds %d bytes", limit), defRequestTooLarge)
+	}
+	ErrTooManyRequests = func(retryAfter int) AppError {
+		return newAppErr(fmt.Errorf("rate limit exceeded, retry after %d seconds", retryAfter), defTooManyRequests)
 	}
 	ErrUnauthorized = func(root error, err ErrorWithKey) AppError {
-		if root == nil {
-			return NewAppErr(errors.New(err.Error()), http.StatusUnauthorized, err.Error()).WithCode(err.Key())
-		}
-		return NewAppErr(root, http.StatusUnauthorized, err.Error()).WithCode(err.Key())
+		return newKeyedAppErr(root, err, defUnauthorized.StatusCode)
+	}
+	ErrForbidden = func(root error, err ErrorWithKey) AppError {
+		return newKeyedAppErr(root, err, defForbidden.StatusCode)
 	}
 )
 
@@ -95,10 +97,52 @@ type AppError struct {
 	Log        string `json:"log"`
 	StatusCode int    `json:"status_code"`
 	Message    string `json:"message"`
+	RequestID  string `json:"request_id,omitempty"`
+	// behind a pointer so AppError stays comparable (==, map key)
+	details *errorDetails
+}
+
+type errorDetails struct {
+	// Invalid fields of the request
+	fields []FieldError
+	// program counters where the error is created, only logged
+	stack []uintptr
 }
 
 func NewAppErr(err error, statusCode int, msg string) AppError {
-	return AppError{RootCause: err, Log: err.Error(), StatusCode: statusCode, Message: msg}
+	return newAppErrAt(err, statusCode, msg, 1)
+}
+
+// newAppErrAt captures stack of its caller, skip frames above it
+func newAppErrAt(err error, statusCode int, msg string, skip int) AppError {
+	return AppError{
+		RootCause:  err,
+		Log:        err.Error(),
+		StatusCode: statusCode,
+		Message:    msg,
+		details:    &errorDetails{stack: callers(skip + 1)},
+	}
+}
+
+func newAppErr(root error, def ErrorDefinition) AppError {
+	return newAppErrAt(root, def.StatusCode, def.Message, 2).WithCode(def.Key)
+}
+
+// root defaults to the keyed error so errors.Is matches it
+func newKeyedAppErr(root error, err ErrorWithKey, statusCode int) AppError {
+	if root == nil {
+		root = err
+	}
+
+	return newAppErrAt(root, statusCode, err.Error(), 2).WithCode(err.Key())
+}
+
+func statusCodeOf(err error, defaultCode int) int {
+	if sc, ok := err.(interface{ StatusCode() int }); ok && sc.StatusCode() > 0 {
+		return sc.StatusCode()
+	}
+
+	return defaultCode
 }
 
 // AppError is error
@@ -106,8 +150,53 @@ func (ae AppError) Error() string {
 	return ae.Message
 }
 
+// Key makes AppError an ErrorWithKey
+func (ae AppError) Key() string {
+	return ae.Code
+}
+
+// Unwrap returns the root cause, errors.Is and errors.As walk through it
+func (ae AppError) Unwrap() error {
+	return ae.RootCause
+}
+
+// Is matches an error having the same key, ex: errors.Is(err, ErrUserNotFound)
+// is true for ErrCustom(dbErr, ErrUserNotFound)
+func (ae AppError) Is(target error) bool {
+	if t, ok := target.(ErrorWithKey); ok && ae.Code != "" {
+		return t.Key() == ae.Code
+	}
+
+	return false
+}
+
+// StackTrace returns where the error is created, empty if it is not
+// created by NewAppErr or the error constructors
+func (ae AppError) StackTrace() []StackFrame {
+	if ae.details == nil {
+		return nil
+	}
+
+	return stackFrames(ae.details.stack)
+}
+
+// FieldErrors returns invalid fields of the error or of its root causes
+func (ae AppError) FieldErrors() []FieldError {
+	if ae.details != nil && len(ae.details.fields) > 0 {
+		return ae.details.fields
+	}
+
+	var root AppError
+	if errors.As(ae.RootCause, &root) {
+		return root.FieldErrors()
+	}
+
+	return nil
+}
+
 func (ae AppError) RootError() error {
-	if root, ok := ae.RootCause.(AppError); ok {
+	var root AppError
+	if errors.As(ae.RootCause, &root) {
 		return root.RootError()
 	}
 
@@ -119,9 +208,76 @@ func (ae AppError) WithCode(code string) AppError {
 	return ae
 }
 
+func (ae AppError) WithRequestID(requestID string) AppError {
+	ae.RequestID = requestID
+	return ae
+}
+
+// WithFields returns a copy of the error listing invalid fields of the request
+func (ae AppError) WithFields(fields []FieldError) AppError {
+	details := errorDetails{}
+	if ae.details != nil {
+		details = *ae.details
+	}
+
+	details.fields = fields
+	ae.details = &details
+
+	return ae
+}
+
+type appErrorJSON struct {
+	Code       string       `json:"code"`
+	Log        string       `json:"log"`
+	StatusCode int          `json:"status_code"`
+	Message    string       `json:"message"`
+	RequestID  string       `json:"request_id,omitempty"`
+	Fields     []FieldError `json:"fields,omitempty"`
+}
+
+// MarshalJSON adds invalid fields of the error, its stack is never serialized
+func (ae AppError) MarshalJSON() ([]byte, error) {
+	var fields []FieldError
+	if ae.details != nil {
+		fields = ae.details.fields
+	}
+
+	return json.Marshal(appErrorJSON{
+		Code:       ae.Code,
+		Log:        ae.Log,
+		StatusCode: ae.StatusCode,
+		Message:    ae.Message,
+		RequestID:  ae.RequestID,
+		Fields:     fields,
+	})
+}
+
+func (ae *AppError) UnmarshalJSON(data []byte) error {
+	var v appErrorJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*ae = AppError{
+		Code:       v.Code,
+		Log:        v.Log,
+		StatusCode: v.StatusCode,
+		Message:    v.Message,
+		RequestID:  v.RequestID,
+	}
+
+	if len(v.Fields) > 0 {
+		*ae = ae.WithFields(v.Fields)
+	}
+
+	return nil
+}
+
 type customError struct {
 	k string
 	v string
+	// 0 is the status of the AppError constructor
+	status int
 }
 
 func (ce *customError) Error() string {
@@ -132,6 +288,24 @@ func (ce *customError) Key() string {
 	return ce.k
 }
 
+func (ce *customError) StatusCode() int {
+	return ce.status
+}
+
+// Is matches custom errors having the same key
+func (ce *customError) Is(target error) bool {
+	t, ok := target.(*customError)
+	return ok && t.k == ce.k
+}
+
+// CustomError declares an error key, it is listed in ErrorCatalog as a bad request
 func CustomError(k, v string) *customError {
-	return &customError{k, v}
+	RegisterError(k, http.StatusBadRequest, v)
+	return &customError{k: k, v: v}
+}
+
+// CustomErrorWithStatus declares an error key responded with status by ErrCustom
+func CustomErrorWithStatus(k string, status int, v string) *customError {
+	RegisterError(k, status, v)
+	return &customError{k: k, v: v, status: status}
 }
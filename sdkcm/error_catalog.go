@@ -0,0 +1,71 @@
+package sdkcm
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ErrorDefinition is a declared error key, listed in the error catalog
+type ErrorDefinition struct {
+	Key        string `json:"key"`
+	StatusCode int    `json:"status_code"`
+	Message    string `json:"message"`
+}
+
+var errorCatalog = struct {
+	mu   sync.RWMutex
+	defs map[string]ErrorDefinition
+}{defs: map[string]ErrorDefinition{}}
+
+// RegisterError adds an error key to the catalog, the first
+// registration of a key wins. CustomError registers its key
+func RegisterError(key string, statusCode int, message string) ErrorDefinition {
+	errorCatalog.mu.Lock()
+	defer errorCatalog.mu.Unlock()
+
+	if def, ok := errorCatalog.defs[key]; ok {
+		return def
+	}
+
+	def := ErrorDefinition{Key: key, StatusCode: statusCode, Message: message}
+	errorCatalog.defs[key] = def
+
+	return def
+}
+
+func LookupError(key string) (ErrorDefinition, bool) {
+	errorCatalog.mu.RLock()
+	defer errorCatalog.mu.RUnlock()
+
+	def, ok := errorCatalog.defs[key]
+	return def, ok
+}
+
+// ErrorCatalog lists all declared error keys sorted by key, ex: for API docs
+func ErrorCatalog() []ErrorDefinition {
+	errorCatalog.mu.RLock()
+	defer errorCatalog.mu.RUnlock()
+
+	defs := make([]ErrorDefinition, 0, len(errorCatalog.defs))
+	for _, def := range errorCatalog.defs {
+		defs = append(defs, def)
+	}
+
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Key < defs[j].Key })
+	return defs
+}
+
+// Errors built by AppError constructors, ErrUnauthorized and ErrForbidden
+// respond any key with the status of their definition
+var (
+	defCannotFetchData = RegisterError("cannot_fetch_data", http.StatusBadRequest, "can not fetch data")
+	defDB              = RegisterError("db_error", http.StatusBadRequest, "db error")
+	defInvalidRequest  = RegisterError("invalid_request", http.StatusBadRequest, "invalid request")
+	defRequestTooLarge = RegisterError("request_too_large", http.StatusRequestEntityTooLarge, "request body is too large")
+	defTooManyRequests = RegisterError("too_many_requests", http.StatusTooManyRequests, "too many requests")
+	defValidation      = RegisterError("validation_failed", http.StatusBadRequest, "invalid request data")
+	defUnauthorized    = RegisterError("unauthorized", http.StatusUnauthorized, "unauthorized")
+	defForbidden       = RegisterError("forbidden", http.StatusForbidden, "you don't have permission to access")
+	_                  = RegisterError("ErrRequestDataInvalid", http.StatusBadRequest, "request data is invalid")
+)
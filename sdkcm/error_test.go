@@ -0,0 +1,105 @@
+package sdkcm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppErrorIs(t *testing.T) {
+	dbErr := errors.New("record not found")
+	err := fmt.Errorf("get user: %w", ErrCustom(dbErr, ErrUserNotFound))
+
+	assert.True(t, errors.Is(err, ErrUserNotFound), "should match key")
+	assert.True(t, errors.Is(err, dbErr), "should match root cause")
+	assert.False(t, errors.Is(err, ErrRoomNotFound), "should not match other key")
+	assert.True(t, errors.Is(ErrUnauthorized(nil, ErrUserNotFound), ErrUserNotFound), "should match key")
+	assert.True(t, errors.Is(ErrRequestDataInvalid("name is empty"), ErrRequestDataInvalid("other")), "should match key")
+
+	var appErr AppError
+	assert.True(t, errors.As(err, &appErr), "should be an AppError")
+	assert.Equal(t, "ErrUserNotFound", appErr.Code, "should be equal")
+	assert.Equal(t, dbErr, appErr.RootError(), "should be equal")
+}
+
+func TestAppErrorStack(t *testing.T) {
+	appErr := ErrDB(errors.New("timeout"))
+
+	stack := appErr.StackTrace()
+	assert.NotEmpty(t, stack, "should have stack")
+	assert.True(t, strings.HasSuffix(stack[0].Function, "TestAppErrorStack"), "should start at the caller")
+
+	data, err := json.Marshal(appErr)
+	assert.Nil(t, err, "must be nil")
+	assert.NotContains(t, string(data), "stack", "should not be serialized")
+}
+
+func TestErrorCatalog(t *testing.T) {
+	CustomErrorWithStatus("ErrOrderNotFound", http.StatusNotFound, "order not found")
+
+	def, ok := LookupError("ErrOrderNotFound")
+	assert.True(t, ok, "should be registered")
+	assert.Equal(t, http.StatusNotFound, def.StatusCode, "should be equal")
+
+	def, ok = LookupError("ErrCannotCreateUser")
+	assert.True(t, ok, "should be registered")
+	assert.Equal(t, http.StatusBadRequest, def.StatusCode, "should be equal")
+
+	_, ok = LookupError("validation_failed")
+	assert.True(t, ok, "should be registered")
+
+	appErr := ErrCustom(nil, CustomErrorWithStatus("ErrOrderNotFound", http.StatusNotFound, "order not found"))
+	assert.Equal(t, http.StatusNotFound, appErr.StatusCode, "should be equal")
+}
+
+// catalog must list the status an error is really responded with
+func TestErrorCatalogStatuses(t *testing.T) {
+	tests := []struct {
+		err AppError
+		key string
+	}{
+		{ErrCustom(nil, ErrUserNotFound), ErrUserNotFound.Key()},
+		{ErrCustom(nil, ErrNoPermission), ErrNoPermission.Key()},
+		{ErrWithMessage(nil, ErrAccessTokenInvalid), ErrAccessTokenInvalid.Key()},
+		{ErrCustom(nil, ErrCannotCreateUser), ErrCannotCreateUser.Key()},
+		{ErrUnauthorized(nil, ErrAccessTokenInvalid), "unauthorized"},
+		{ErrForbidden(nil, ErrNoPermission), "forbidden"},
+		{ErrRequestTooLarge(1024), "request_too_large"},
+		{ErrTooManyRequests(1), "too_many_requests"},
+		{ErrValidation(nil), "validation_failed"},
+	}
+
+	for _, tt := range tests {
+		def, ok := LookupError(tt.key)
+		assert.True(t, ok, "%s should be registered", tt.key)
+		assert.Equal(t, tt.err.StatusCode, def.StatusCode, "%s should be equal", tt.key)
+	}
+
+	// keys keep their status with ErrCustom, constructors decide theirs
+	assert.Equal(t, http.StatusBadRequest, ErrCustom(nil, ErrUserNotFound).StatusCode, "should be equal")
+	assert.Equal(t, http.StatusUnauthorized, ErrUnauthorized(nil, ErrUserNotFound).StatusCode, "should be equal")
+	assert.Equal(t, http.StatusForbidden, ErrForbidden(nil, ErrNoPermission).StatusCode, "should be equal")
+	assert.Equal(t, ErrNoPermission.Key(), ErrForbidden(nil, ErrNoPermission).Code, "should keep the key")
+}
+
+func TestAppErrorComparable(t *testing.T) {
+	appErr := ErrValidation([]FieldError{NewFieldError("email", "email", "email is invalid")})
+
+	assert.True(t, appErr == appErr, "should be comparable")
+	seen := map[error]bool{appErr: true}
+	assert.True(t, seen[appErr], "should be usable as a map key")
+
+	data, err := json.Marshal(appErr)
+	assert.Nil(t, err, "must be nil")
+	assert.Contains(t, string(data), `"fields":[{"field":"email"`, "should serialize fields")
+
+	var decoded AppError
+	assert.Nil(t, json.Unmarshal(data, &decoded), "must be nil")
+	assert.Equal(t, appErr.FieldErrors(), decoded.FieldErrors(), "should be equal")
+	assert.Equal(t, appErr.Code, decoded.Code, "should be equal")
+}
@@ -0,0 +1,43 @@
+package sdkcm
+
+import (
+	"errors"
+	"strings"
+)
+
+// FieldError describes why a field of the request is invalid
+type FieldError struct {
+	// Name of the field in the request (json, form or query name)
+	Field string `json:"field"`
+	// Rule failed, ex: required, min, email
+	Rule string `json:"rule"`
+	// Human-readable message
+	Message string `json:"message"`
+	// Machine-readable code, ex: field_required
+	Code string `json:"code"`
+}
+
+func NewFieldError(field, rule, message string) FieldError {
+	return FieldError{
+		Field:   field,
+		Rule:    rule,
+		Message: message,
+		Code:    "field_" + strings.ToLower(rule),
+	}
+}
+
+func (fe FieldError) Error() string {
+	return fe.Field + ": " + fe.Message
+}
+
+// ErrValidation is an AppError 400 listing all invalid fields
+var ErrValidation = func(fields []FieldError) AppError {
+	msgs := make([]string, len(fields))
+	for i := range fields {
+		msgs[i] = fields[i].Error()
+	}
+
+	return newAppErrAt(errors.New(strings.Join(msgs, "; ")), defValidation.StatusCode, defValidation.Message, 1).
+		WithCode(defValidation.Key).
+		WithFields(fields)
+}
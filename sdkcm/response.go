@@ -13,17 +13,21 @@ var (
 
 	ResponseWithPaging = func(data, param interface{}, other interface{}) Response {
 		if v, ok := other.(Paging); ok {
-			if v.NextCursor != "" {
-				if !v.CursorIsUID {
-					v.NextCursor = base58.Encode([]byte(v.NextCursor))
-				}
-			}
-			return newResponse(http.StatusOK, data, param, v)
+			return newResponse(http.StatusOK, data, param, v.encodeCursor())
 		}
 		return newResponse(http.StatusOK, data, param, other)
 	}
 )
 
+// Cursor which is not an UID is base58 encoded for clients
+func (p Paging) encodeCursor() Paging {
+	if p.NextCursor != "" && !p.CursorIsUID {
+		p.NextCursor = base58.Encode([]byte(p.NextCursor))
+	}
+
+	return p
+}
+
 type Response struct {
 	Code   int         `json:"code"`
 	Data   interface{} `json:"data"`
@@ -39,3 +43,37 @@ func newResponse(code int, data, param, other interface{}) Response {
 		Paging: other,
 	}
 }
+
+// DataResponse is Response with typed data. It is not named Response[T]
+// because Go does not allow a generic type to share the name of the
+// existing non-generic Response, which is kept for compatibility
+type DataResponse[T any] struct {
+	Code int `json:"code"`
+	Data T   `json:"data"`
+}
+
+func NewDataResponse[T any](data T) DataResponse[T] {
+	return DataResponse[T]{Code: http.StatusOK, Data: data}
+}
+
+// ListResponse is Response of a list with its filter and paging.
+// Nil data is written as an empty list
+type ListResponse[T any] struct {
+	Code   int         `json:"code"`
+	Data   []T         `json:"data"`
+	Param  interface{} `json:"param,omitempty"`
+	Paging *Paging     `json:"paging,omitempty"`
+}
+
+func NewListResponse[T any](data []T, filter interface{}, paging *Paging) ListResponse[T] {
+	if data == nil {
+		data = []T{}
+	}
+
+	if paging != nil {
+		encoded := paging.encodeCursor()
+		paging = &encoded
+	}
+
+	return ListResponse[T]{Code: http.StatusOK, Data: data, Param: filter, Paging: paging}
+}
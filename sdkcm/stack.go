@@ -0,0 +1,50 @@
+package sdkcm
+
+import (
+	"fmt"
+	"runtime"
+)
+
+const maxStackDepth = 32
+
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+func (f StackFrame) String() string {
+	return fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line)
+}
+
+// callers skips runtime.Callers, callers and skip frames above them
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs)
+	return pcs[:n]
+}
+
+func stackFrames(pcs []uintptr) []StackFrame {
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	frames := runtime.CallersFrames(pcs)
+	stack := make([]StackFrame, 0, len(pcs))
+
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, StackFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+
+		if !more {
+			break
+		}
+	}
+
+	return stack
+}
+
+// CallerStack returns stack of the caller, skip 0 is the caller itself
+func CallerStack(skip int) []StackFrame {
+	return stackFrames(callers(skip + 1))
+}
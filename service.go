@@ -12,7 +12,9 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 )
 
 const (
@@ -29,20 +31,55 @@ type service struct {
 	opts         []Option
 	subServices  []Runnable
 	initServices map[string]PrefixRunnable
+	initPrefixes []string
+	initDeps     map[string][]string
+	initSorted   []PrefixRunnable
 	isRegister   bool
 	logger       logger.Logger
 	httpServer   HttpServer
+	// extra http servers by prefix
+	httpServers  map[string]HttpServer
+	httpPrefixes []string
 	signalChan   chan os.Signal
 	cmdLine      *AppFlagSet
-	stopFunc     func()
+	envFile      string
+	configFile   string
+	outEnvFormat string
+	validateOnly bool
+	// problems found by validate, returned by Init and Start
+	configErr error
+	// variables set before loading env file
+	processEnv map[string]bool
+	health     *healthMonitor
+	metrics    metricsEndpoint
+	// directory of translation files: vi.json, ja.yaml...
+	i18nDir string
+	states  *componentStates
+	// closed when service starts stopping
+	stopping        chan struct{}
+	stoppingOnce    *sync.Once
+	restartPolicies map[string]RestartPolicy
+	startedAt       time.Time
+	stopTimeouts    map[string]time.Duration
+	// in seconds, 0 means no limit
+	shutdownTimeout int
+	stopFunc        func()
 }
 
 func New(opts ...Option) Service {
 	sv := &service{
-		opts:         opts,
-		signalChan:   make(chan os.Signal, 1),
-		subServices:  []Runnable{},
-		initServices: map[string]PrefixRunnable{},
+		opts:            opts,
+		signalChan:      make(chan os.Signal, 1),
+		subServices:     []Runnable{},
+		initServices:    map[string]PrefixRunnable{},
+		initDeps:        map[string][]string{},
+		httpServers:     map[string]HttpServer{},
+		health:          newHealthMonitor(),
+		states:          newComponentStates(),
+		stopping:        make(chan struct{}),
+		stoppingOnce:    new(sync.Once),
+		restartPolicies: map[string]RestartPolicy{},
+		stopTimeouts:    map[string]time.Duration{},
 	}
 
 	// init default logger
@@ -59,6 +96,14 @@ func New(opts ...Option) Service {
 
 	sv.subServices = append(sv.subServices, httpServer)
 
+	for _, prefix := range sv.httpPrefixes {
+		server := httpserver.NewWithPrefix(sv.name, prefix)
+		sv.httpServers[prefix] = server
+		sv.subServices = append(sv.subServices, server)
+	}
+
+	sv.subServices = append(sv.subServices, newAdminServer(sv))
+
 	sv.initFlags()
 
 	if sv.name == "" {
@@ -67,14 +112,32 @@ func New(opts ...Option) Service {
 		}
 	}
 
+	sv.initMetrics()
+
 	loggerRunnable := logger.GetCurrent().(Runnable)
 	loggerRunnable.InitFlags()
 
 	sv.cmdLine = newFlagSet(sv.name, flag.CommandLine)
 	sv.parseFlags()
 
+	sv.httpServer.ShowErrorLog(sv.env == DevEnv)
+	for _, server := range sv.httpServers {
+		server.ShowErrorLog(sv.env == DevEnv)
+	}
+
 	_ = loggerRunnable.Configure()
 
+	sv.configErr = sv.validate()
+
+	if sv.validateOnly {
+		if sv.configErr != nil {
+			sv.logger.Fatalln(sv.configErr.Error())
+		}
+
+		sv.logger.Infoln("config is valid")
+		os.Exit(0)
+	}
+
 	return sv
 }
 
@@ -87,10 +150,30 @@ func (s *service) Version() string {
 }
 
 func (s *service) Init() error {
-	for _, dbSv := range s.initServices {
+	if s.configErr != nil {
+		return s.configErr
+	}
+
+	sorted, err := s.sortInitServices()
+	if err != nil {
+		return err
+	}
+
+	s.initSorted = sorted
+
+	if err := s.loadMessages(); err != nil {
+		return err
+	}
+
+	for _, dbSv := range s.initSorted {
+		s.states.transit(dbSv, StateStarting, nil)
+
 		if err := dbSv.Run(); err != nil {
+			s.states.transit(dbSv, StateFailed, err)
 			return err
 		}
+
+		s.states.transit(dbSv, StateRunning, nil)
 	}
 
 	return nil
@@ -101,7 +184,14 @@ func (s *service) IsRegistered() bool {
 }
 
 func (s *service) Start() error {
+	if s.configErr != nil {
+		return s.configErr
+	}
+
 	signal.Notify(s.signalChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	s.startedAt = time.Now().UTC()
+	s.startHealthMonitor()
+	s.mountMetrics()
 	c := s.run()
 	//s.stopFunc = s.activeRegistry()
 
@@ -110,18 +200,16 @@ func (s *service) Start() error {
 		case err := <-c:
 			if err != nil {
 				s.logger.Error(err.Error())
-				s.Stop()
-				return err
+				return s.stop(err)
 			}
 
 		case sig := <-s.signalChan:
 			s.logger.Infoln(sig)
 			switch sig {
 			case syscall.SIGHUP:
-				return nil
+				s.reload()
 			default:
-				s.Stop()
-				return nil
+				return s.stop(nil)
 			}
 		}
 	}
@@ -129,13 +217,43 @@ func (s *service) Start() error {
 
 func (s *service) initFlags() {
 	flag.StringVar(&s.env, "app-env", DevEnv, "Env for service. Ex: dev | stg | prd")
+	flag.StringVar(&s.configFile, configFlagName, "", "Config file (.yaml, .yml, .json, .toml). Its values are overridden by env and command line")
+	flag.StringVar(&s.outEnvFormat, "outenv-format", ConfigFormatEnv, "Format of OutEnv: env | yaml | json | toml")
+	flag.BoolVar(&s.validateOnly, "validate-only", false, "Validate config of all components then exit, for CI")
+	flag.BoolVar(&s.health.enabled, "health-check", true, "Mount /healthz and /readyz on the HTTP server when it is enabled")
+	flag.IntVar(&s.health.interval, "health-check-interval", 5, "Interval (in seconds) to check health of components")
+	flag.StringVar(&s.metrics.path, "metrics-path", "/metrics", "Path of Prometheus metrics endpoint, disabled if empty")
+	flag.StringVar(&s.metrics.server, "metrics-server", "", "Prefix of the HTTP server (see WithHttpServer) serving metrics, it needs a port. Empty serves metrics on the admin address (-"+adminAddrFlagName+") only")
+	flag.StringVar(&s.i18nDir, "i18n-dir", "", "Directory of error message translations, one file per language: vi.json, ja.yaml...")
+	flag.IntVar(&s.shutdownTimeout, "shutdown-timeout", 30, "Deadline (in seconds) for all components to stop, 0 means no limit")
 
 	for _, subService := range s.subServices {
 		subService.InitFlags()
 	}
 
-	for _, dbService := range s.initServices {
-		dbService.InitFlags()
+	for _, prefix := range s.initPrefixes {
+		s.initServices[prefix].InitFlags()
+	}
+}
+
+// Collect components reporting their health and check them in background
+func (s *service) startHealthMonitor() {
+	if !s.health.enabled {
+		return
+	}
+
+	for _, subService := range s.subServices {
+		s.health.add(subService)
+	}
+
+	for _, prefix := range s.initPrefixes {
+		s.health.add(s.initServices[prefix])
+	}
+
+	go s.health.run()
+
+	if s.httpServer.IsEnabled() {
+		s.httpServer.AddHandler(s.health.registerRoutes)
 	}
 }
 
@@ -145,30 +263,17 @@ func (s *service) run() <-chan error {
 
 	// Start all services
 	for _, subService := range s.subServices {
-		go func(subSv Runnable) { c <- subSv.Run() }(subService)
+		go func(subSv Runnable) { c <- s.runWithPolicy(subSv) }(subService)
 	}
 
 	return c
 }
 
-// Stop service and stop its components at the same time
+// Stop service and its components, errors are logged
 func (s *service) Stop() {
-	s.logger.Infoln("Stopping service...")
-	stopChan := make(chan bool)
-	for _, subService := range s.subServices {
-		go func(subSv Runnable) { stopChan <- <-subSv.Stop() }(subService)
-	}
-
-	for _, dbSv := range s.initServices {
-		go func(subSv Runnable) { stopChan <- <-subSv.Stop() }(dbSv)
-	}
-
-	for i := 0; i < len(s.subServices)+len(s.initServices); i++ {
-		<-stopChan
+	if err := s.stop(nil); err != nil {
+		s.logger.Errorln(err.Error())
 	}
-
-	//s.stopFunc()
-	s.logger.Infoln("service stopped")
 }
 
 func (s *service) RunFunction(fn Function) error {
@@ -179,12 +284,23 @@ func (s *service) HTTPServer() HttpServer {
 	return s.httpServer
 }
 
+func (s *service) HTTPServerWithPrefix(prefix string) (HttpServer, bool) {
+	if prefix == "" {
+		return s.httpServer, true
+	}
+
+	server, ok := s.httpServers[prefix]
+	return server, ok
+}
+
 func (s *service) Logger(prefix string) logger.Logger {
 	return logger.GetCurrent().GetLogger(prefix)
 }
 
 func (s *service) OutEnv() {
-	s.cmdLine.GetSampleEnvs()
+	if err := s.cmdLine.GetSampleConfig(s.outEnvFormat); err != nil {
+		s.logger.Errorln(err.Error())
+	}
 }
 
 func (s *service) parseFlags() {
@@ -193,6 +309,9 @@ func (s *service) parseFlags() {
 		envFile = ".env"
 	}
 
+	s.envFile = envFile
+	s.processEnv = getProcessEnv()
+
 	_, err := os.Stat(envFile)
 	if err == nil {
 		err := godotenv.Load(envFile)
@@ -203,7 +322,9 @@ func (s *service) parseFlags() {
 		s.logger.Fatalf("Loading env(%s): %s", envFile, err.Error())
 	}
 
-	s.cmdLine.Parse([]string{})
+	if err := s.cmdLine.Parse(s.cmdLine.knownArgs(os.Args[1:])); err != nil {
+		s.logger.Fatalf("Parsing flags: %s", err.Error())
+	}
 }
 
 // Service must have a name for service discovery and logging/monitoring
@@ -224,23 +345,63 @@ func WithFileLogger() Option {
 }
 
 // Add Runnable component to SDK
-// These components will run parallel in when service run
-func WithRunnable(r Runnable) Option {
-	return func(s *service) { s.subServices = append(s.subServices, r) }
+// These components will run parallel in when service run.
+// By default a failed component stops the service, a RestartPolicy changes it
+func WithRunnable(r Runnable, policy ...RestartPolicy) Option {
+	return func(s *service) {
+		s.subServices = append(s.subServices, r)
+
+		if len(policy) > 0 {
+			s.restartPolicies[r.Name()] = policy[0]
+		}
+	}
+}
+
+// Add another HTTP server to SDK, it has its own flags (prefixed), handlers
+// and lifecycle. Ex: WithHttpServer("internal") for metrics/admin routes
+// on internal-port. Get it with HTTPServerWithPrefix
+func WithHttpServer(prefix string) Option {
+	return func(s *service) {
+		if _, ok := s.httpServers[prefix]; ok || prefix == "" {
+			log.Fatal(fmt.Sprintf("http server prefix %q is empty or duplicated", prefix))
+		}
+
+		// flags of these prefixes clash with the default server and admin flags
+		if prefix == "gin" || prefix == "admin-server" {
+			log.Fatal(fmt.Sprintf("http server prefix %q is reserved", prefix))
+		}
+
+		s.httpServers[prefix] = nil
+		s.httpPrefixes = append(s.httpPrefixes, prefix)
+	}
 }
 
 // Add init component to SDK
-// These components will run sequentially before service run
-func WithInitRunnable(r PrefixRunnable) Option {
+// These components will run sequentially before service run.
+// dependsOn lists prefixes of init components that must run before this one,
+// the component can also declare them by implementing HasDependencies
+func WithInitRunnable(r PrefixRunnable, dependsOn ...string) Option {
 	return func(s *service) {
 		if _, ok := s.initServices[r.GetPrefix()]; ok {
 			log.Fatal(fmt.Sprintf("prefix %s is duplicated", r.GetPrefix()))
 		}
 
 		s.initServices[r.GetPrefix()] = r
+		s.initPrefixes = append(s.initPrefixes, r.GetPrefix())
+
+		if len(dependsOn) > 0 {
+			s.initDeps[r.GetPrefix()] = dependsOn
+		}
 	}
 }
 
+// Set a stop timeout for a component (by its name). It can only shorten the
+// global shutdown deadline (-shutdown-timeout), never extend it.
+// A component missing its deadline is force-closed if it implements ForceStopper
+func WithStopTimeout(name string, timeout time.Duration) Option {
+	return func(s *service) { s.stopTimeouts[name] = timeout }
+}
+
 func (s *service) Get(prefix string) (interface{}, bool) {
 	is, ok := s.initServices[prefix]
 
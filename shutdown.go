@@ -0,0 +1,130 @@
+// Copyright (c) 2019, Viet Tran, 200Lab Team.
+
+package goservice
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ShutdownError lists components which did not stop before their deadline
+type ShutdownError struct {
+	// Error made the service stop, nil when stopped by a signal
+	Cause      error
+	Components []string
+}
+
+func (e *ShutdownError) Error() string {
+	msg := fmt.Sprintf("components did not stop in time: %s", strings.Join(e.Components, ", "))
+
+	if e.Cause != nil {
+		return e.Cause.Error() + "; " + msg
+	}
+
+	return msg
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Cause
+}
+
+// Stop service and stop its components at the same time,
+// init components are stopped after them in reverse order of their startup.
+// Every component has to stop before the shutdown deadline, otherwise
+// it is force-closed and reported in the returned error
+func (s *service) stop(cause error) error {
+	s.logger.Infoln("Stopping service...")
+	s.health.stop()
+	s.stoppingOnce.Do(func() { close(s.stopping) })
+
+	var deadline time.Time
+	if s.shutdownTimeout > 0 {
+		deadline = time.Now().Add(time.Second * time.Duration(s.shutdownTimeout))
+	}
+
+	var timedOut []string
+	mu := new(sync.Mutex)
+	wg := new(sync.WaitGroup)
+
+	for _, subService := range s.subServices {
+		wg.Add(1)
+
+		go func(subSv Runnable) {
+			defer wg.Done()
+
+			if !s.stopWithDeadline(subSv, deadline) {
+				mu.Lock()
+				timedOut = append(timedOut, subSv.Name())
+				mu.Unlock()
+			}
+		}(subService)
+	}
+
+	wg.Wait()
+
+	initServices := s.initSorted
+	if initServices == nil {
+		// Init has not run, fallback to registration order
+		for _, prefix := range s.initPrefixes {
+			initServices = append(initServices, s.initServices[prefix])
+		}
+	}
+
+	for i := len(initServices) - 1; i >= 0; i-- {
+		if !s.stopWithDeadline(initServices[i], deadline) {
+			timedOut = append(timedOut, initServices[i].Name())
+		}
+	}
+
+	//s.stopFunc()
+	s.logger.Infoln("service stopped")
+
+	if len(timedOut) > 0 {
+		return &ShutdownError{Cause: cause, Components: timedOut}
+	}
+
+	return cause
+}
+
+// stopWithDeadline returns false when the component misses its deadline,
+// a zero deadline means waiting until it stops
+func (s *service) stopWithDeadline(r Runnable, deadline time.Time) bool {
+	if timeout, ok := s.stopTimeouts[r.Name()]; ok {
+		if d := time.Now().Add(timeout); deadline.IsZero() || d.Before(deadline) {
+			deadline = d
+		}
+	}
+
+	s.states.transit(r, StateStopping, nil)
+	stopChan := r.Stop()
+
+	if deadline.IsZero() {
+		<-stopChan
+		s.states.transit(r, StateStopped, nil)
+		return true
+	}
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	select {
+	case <-stopChan:
+		s.states.transit(r, StateStopped, nil)
+		return true
+	case <-timer.C:
+	}
+
+	s.states.transit(r, StateFailed, errors.New("did not stop before shutdown deadline"))
+	s.logger.Errorf("%s did not stop before shutdown deadline, force closing it", r.Name())
+
+	if fs, ok := r.(ForceStopper); ok {
+		if err := fs.ForceStop(); err != nil {
+			s.logger.Errorf("cannot force close %s: %s", r.Name(), err.Error())
+		}
+	}
+
+	return false
+}
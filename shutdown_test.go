@@ -0,0 +1,90 @@
+package goservice
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockSlowStopper stops only when it is force-stopped
+type mockSlowStopper struct {
+	mockRunnable
+	release chan bool
+	forced  bool
+}
+
+func newMockSlowStopper(name string) *mockSlowStopper {
+	return &mockSlowStopper{mockRunnable: mockRunnable{name: name}, release: make(chan bool, 1)}
+}
+
+func (m *mockSlowStopper) Stop() <-chan bool { return m.release }
+
+func (m *mockSlowStopper) ForceStop() error {
+	m.forced = true
+	m.release <- true
+	return nil
+}
+
+func newShutdownTestService(shutdownTimeout int, opts ...Option) *service {
+	s := newStateTestService()
+	s.health = newHealthMonitor()
+	s.stopTimeouts = map[string]time.Duration{}
+	s.stoppingOnce = new(sync.Once)
+	s.shutdownTimeout = shutdownTimeout
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func TestStopForceClosesSlowComponents(t *testing.T) {
+	slow := newMockSlowStopper("slow")
+	s := newShutdownTestService(1)
+	fast := &mockRunnable{name: "fast"}
+	s.subServices = []Runnable{fast, slow}
+
+	cause := errors.New("port is in use")
+	err := s.stop(cause)
+
+	var shutdownErr *ShutdownError
+	assert.True(t, errors.As(err, &shutdownErr), "should be a ShutdownError")
+	assert.Equal(t, []string{"slow"}, shutdownErr.Components, "should be equal")
+	assert.True(t, errors.Is(err, cause), "should wrap the cause")
+	assert.True(t, slow.forced, "slow component must be force-stopped")
+	assert.Equal(t, StateFailed, s.states.get(slow).state, "should be equal")
+	assert.Equal(t, StateStopped, s.states.get(fast).state, "should be equal")
+}
+
+func TestStopTimeoutShortensDeadline(t *testing.T) {
+	slow := newMockSlowStopper("slow")
+	s := newShutdownTestService(30, WithStopTimeout("slow", time.Millisecond*50))
+	s.subServices = []Runnable{slow}
+
+	start := time.Now()
+	err := s.stop(nil)
+
+	assert.NotNil(t, err, "must not be nil")
+	assert.True(t, slow.forced, "slow component must be force-stopped")
+	assert.Less(t, time.Since(start), time.Second, "component timeout must shorten the deadline")
+
+	// a component timeout longer than the shutdown deadline does not extend it
+	slow = newMockSlowStopper("slow")
+	s = newShutdownTestService(1, WithStopTimeout("slow", time.Minute))
+	s.subServices = []Runnable{slow}
+
+	start = time.Now()
+	assert.NotNil(t, s.stop(nil), "must not be nil")
+	assert.Less(t, time.Since(start), time.Second*5, "shutdown deadline must not be extended")
+}
+
+func TestStopWithoutDeadline(t *testing.T) {
+	s := newShutdownTestService(0)
+	s.subServices = []Runnable{&mockRunnable{name: "fast"}}
+
+	assert.Nil(t, s.stop(nil), "must be nil")
+}
@@ -0,0 +1,256 @@
+// Copyright (c) 2019, Viet Tran, 200Lab Team.
+
+package goservice
+
+import (
+	"sync"
+	"time"
+)
+
+type ComponentState string
+
+const (
+	StateRegistered ComponentState = "registered"
+	StateStarting   ComponentState = "starting"
+	StateRunning    ComponentState = "running"
+	StateRestarting ComponentState = "restarting"
+	StateStopping   ComponentState = "stopping"
+	StateStopped    ComponentState = "stopped"
+	StateFailed     ComponentState = "failed"
+)
+
+// Allowed transitions of component lifecycle:
+//
+//	registered -> starting -> running -> stopping -> stopped
+//	                      \-> failed -> restarting -> starting
+//
+// A component can fail while starting, running or stopping
+// and can be stopped from any state except stopped.
+// Run returning nil while starting or running (ex: a disabled server) is stopped
+var stateTransitions = map[ComponentState][]ComponentState{
+	StateRegistered: {StateStarting, StateStopping},
+	StateStarting:   {StateRunning, StateFailed, StateStopping, StateStopped},
+	StateRunning:    {StateFailed, StateStopping, StateStopped},
+	StateFailed:     {StateRestarting, StateStopping},
+	StateRestarting: {StateStarting, StateStopping},
+	StateStopping:   {StateStopped, StateFailed},
+	StateStopped:    {},
+}
+
+// Used when the restart backoff is not positive, avoids a restart hot loop
+const defaultRestartBackoff = time.Second
+
+// RestartPolicy tells service what to do when Run of a component returns an error
+type RestartPolicy struct {
+	OnFailure bool
+	// 0 means unlimited
+	MaxRetries int
+	// Delay before the first restart, it is doubled after each failure.
+	// Not positive means defaultRestartBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// Failed component stops the service, this is the default policy
+func RestartNever() RestartPolicy {
+	return RestartPolicy{}
+}
+
+// Failed component is restarted with exponential backoff (capped at 1 minute),
+// the service only stops when the component fails more than maxRetries times.
+// A backoff not positive is 1 second
+func RestartOnFailure(maxRetries int, backoff time.Duration) RestartPolicy {
+	if backoff <= 0 {
+		backoff = defaultRestartBackoff
+	}
+
+	return RestartPolicy{
+		OnFailure:  true,
+		MaxRetries: maxRetries,
+		Backoff:    backoff,
+		MaxBackoff: time.Minute,
+	}
+}
+
+type componentStatus struct {
+	state    ComponentState
+	err      error
+	restarts int
+}
+
+// Tracks lifecycle state of every component and fires lifecycle hooks
+type componentStates struct {
+	mu *sync.RWMutex
+	// keyed by component name, a Runnable value may not be hashable
+	statuses  map[string]*componentStatus
+	onStarted []func(r Runnable)
+	onStopped []func(r Runnable)
+	onFailed  []func(r Runnable, err error)
+}
+
+func newComponentStates() *componentStates {
+	return &componentStates{
+		mu:       new(sync.RWMutex),
+		statuses: map[string]*componentStatus{},
+	}
+}
+
+// transit moves the component to a new state, invalid transitions are ignored.
+// Hooks are called synchronously after the state has changed
+func (cs *componentStates) transit(r Runnable, to ComponentState, err error) bool {
+	cs.mu.Lock()
+
+	st, ok := cs.statuses[r.Name()]
+	if !ok {
+		st = &componentStatus{state: StateRegistered}
+		cs.statuses[r.Name()] = st
+	}
+
+	if !canTransit(st.state, to) {
+		cs.mu.Unlock()
+		return false
+	}
+
+	if to == StateRestarting {
+		st.restarts++
+	}
+
+	st.state = to
+	st.err = err
+	cs.mu.Unlock()
+
+	switch to {
+	case StateRunning:
+		for _, hook := range cs.onStarted {
+			hook(r)
+		}
+	case StateStopped:
+		for _, hook := range cs.onStopped {
+			hook(r)
+		}
+	case StateFailed:
+		for _, hook := range cs.onFailed {
+			hook(r, err)
+		}
+	}
+
+	return true
+}
+
+func canTransit(from, to ComponentState) bool {
+	for _, state := range stateTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cs *componentStates) get(r Runnable) componentStatus {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	if st, ok := cs.statuses[r.Name()]; ok {
+		return *st
+	}
+
+	return componentStatus{state: StateRegistered}
+}
+
+// runWithPolicy runs a component and restarts it on failure
+// according to its restart policy until the service is stopping
+func (s *service) runWithPolicy(r Runnable) error {
+	policy := s.restartPolicies[r.Name()]
+	backoff := policy.firstBackoff()
+
+	for retries := 0; ; retries++ {
+		if !s.states.transit(r, StateStarting, nil) {
+			// service is stopping
+			return nil
+		}
+
+		err := s.runOnce(r)
+		if err == nil {
+			s.states.transit(r, StateStopped, nil)
+			return nil
+		}
+
+		if !s.states.transit(r, StateFailed, err) {
+			// error while stopping
+			return nil
+		}
+
+		if !policy.OnFailure || (policy.MaxRetries > 0 && retries >= policy.MaxRetries) {
+			return err
+		}
+
+		s.logger.Errorf("%s failed: %s. Restart in %s", r.Name(), err.Error(), backoff)
+
+		if !s.states.transit(r, StateRestarting, nil) {
+			return nil
+		}
+
+		select {
+		case <-s.stopping:
+			return nil
+		case <-time.After(backoff):
+		}
+
+		backoff = policy.nextBackoff(backoff)
+	}
+}
+
+// runOnce runs the component, it is running when it notifies it has started
+// (StartNotifier) or as soon as Run is called when it cannot tell
+func (s *service) runOnce(r Runnable) error {
+	notifier, ok := r.(StartNotifier)
+	if !ok {
+		s.states.transit(r, StateRunning, nil)
+		return r.Run()
+	}
+
+	started := notifier.Started()
+	done := make(chan error, 1)
+	go func() { done <- r.Run() }()
+
+	select {
+	case <-started:
+		s.states.transit(r, StateRunning, nil)
+		return <-done
+	case err := <-done:
+		return err
+	}
+}
+
+func (p RestartPolicy) firstBackoff() time.Duration {
+	if p.Backoff <= 0 {
+		return defaultRestartBackoff
+	}
+
+	return p.Backoff
+}
+
+// nextBackoff doubles backoff up to MaxBackoff
+func (p RestartPolicy) nextBackoff(backoff time.Duration) time.Duration {
+	if backoff *= 2; p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+
+	return backoff
+}
+
+// Called when a component is running, it is also called after every restart
+func WithOnStarted(hook func(r Runnable)) Option {
+	return func(s *service) { s.states.onStarted = append(s.states.onStarted, hook) }
+}
+
+// Called when a component has stopped in time
+func WithOnStopped(hook func(r Runnable)) Option {
+	return func(s *service) { s.states.onStopped = append(s.states.onStopped, hook) }
+}
+
+// Called when Run of a component returns an error or it misses shutdown deadline
+func WithOnFailed(hook func(r Runnable, err error)) Option {
+	return func(s *service) { s.states.onFailed = append(s.states.onFailed, hook) }
+}
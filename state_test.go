@@ -0,0 +1,154 @@
+package goservice
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/200Lab-Education/go-sdk/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingRunnable struct {
+	mockRunnable
+	runs int
+}
+
+func (f *failingRunnable) Run() error {
+	f.runs++
+	return errors.New("boom")
+}
+
+// never notifies it has started, like a disabled server
+type disabledRunnable struct {
+	mockRunnable
+}
+
+func (d *disabledRunnable) Started() <-chan struct{} { return make(chan struct{}) }
+
+func newStateTestService(opts ...Option) *service {
+	logger.InitServLogger(false)
+
+	s := &service{
+		states:          newComponentStates(),
+		restartPolicies: map[string]RestartPolicy{},
+		stopping:        make(chan struct{}),
+		logger:          logger.GetCurrent().GetLogger("test"),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func TestCanTransit(t *testing.T) {
+	tests := []struct {
+		from, to ComponentState
+		want     bool
+	}{
+		{StateRegistered, StateStarting, true},
+		{StateRegistered, StateRunning, false},
+		{StateStarting, StateRunning, true},
+		{StateStarting, StateStopped, true},
+		{StateRunning, StateFailed, true},
+		{StateRunning, StateStopped, true},
+		{StateRunning, StateStarting, false},
+		{StateFailed, StateRestarting, true},
+		{StateFailed, StateRunning, false},
+		{StateRestarting, StateStarting, true},
+		{StateStopping, StateStopped, true},
+		{StateStopping, StateStarting, false},
+		{StateStopped, StateStarting, false},
+		{StateStopped, StateStopping, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, canTransit(tt.from, tt.to), "%s -> %s", tt.from, tt.to)
+	}
+}
+
+func TestComponentStatesKeyedByName(t *testing.T) {
+	cs := newComponentStates()
+
+	assert.True(t, cs.transit(&mockRunnable{name: "worker"}, StateStarting, nil), "should be true")
+	assert.Equal(t, StateStarting, cs.get(&mockRunnable{name: "worker"}).state, "should be equal")
+	assert.False(t, cs.transit(&mockRunnable{name: "worker"}, StateRestarting, nil), "should be false")
+}
+
+func TestNextBackoff(t *testing.T) {
+	p := RestartPolicy{Backoff: time.Second, MaxBackoff: 3 * time.Second}
+
+	assert.Equal(t, time.Second, p.firstBackoff(), "should be equal")
+	assert.Equal(t, 2*time.Second, p.nextBackoff(time.Second), "should be equal")
+	assert.Equal(t, 3*time.Second, p.nextBackoff(2*time.Second), "should be capped")
+	assert.Equal(t, defaultRestartBackoff, RestartPolicy{}.firstBackoff(), "should be equal")
+	assert.Equal(t, defaultRestartBackoff, RestartOnFailure(1, 0).Backoff, "should be equal")
+}
+
+func TestRunWithPolicyMaxRetries(t *testing.T) {
+	r := &failingRunnable{mockRunnable: mockRunnable{name: "worker"}}
+
+	var started, failed int
+	s := newStateTestService(
+		WithRunnable(r, RestartPolicy{OnFailure: true, MaxRetries: 2, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}),
+		WithOnStarted(func(Runnable) { started++ }),
+		WithOnFailed(func(Runnable, error) { failed++ }),
+	)
+
+	err := s.runWithPolicy(r)
+
+	assert.EqualError(t, err, "boom", "should be equal")
+	assert.Equal(t, 3, r.runs, "should run once and restart twice")
+	assert.Equal(t, 3, started, "should be equal")
+	assert.Equal(t, 3, failed, "should be equal")
+
+	status := s.states.get(r)
+	assert.Equal(t, StateFailed, status.state, "should be equal")
+	assert.Equal(t, 2, status.restarts, "should be equal")
+}
+
+func TestRunWithPolicyNever(t *testing.T) {
+	r := &failingRunnable{mockRunnable: mockRunnable{name: "worker"}}
+	s := newStateTestService(WithRunnable(r))
+
+	assert.NotNil(t, s.runWithPolicy(r), "must not be nil")
+	assert.Equal(t, 1, r.runs, "should be equal")
+}
+
+func TestRunWithPolicyStopsWhileBackingOff(t *testing.T) {
+	r := &failingRunnable{mockRunnable: mockRunnable{name: "worker"}}
+	s := newStateTestService(WithRunnable(r, RestartOnFailure(0, time.Hour)))
+
+	done := make(chan error, 1)
+	go func() { done <- s.runWithPolicy(r) }()
+
+	time.Sleep(10 * time.Millisecond)
+	close(s.stopping)
+
+	select {
+	case err := <-done:
+		assert.Nil(t, err, "must be nil")
+	case <-time.After(time.Second):
+		t.Fatal("should return when service is stopping")
+	}
+
+	assert.Equal(t, 1, r.runs, "should be equal")
+	assert.Equal(t, StateRestarting, s.states.get(r).state, "should be equal")
+}
+
+func TestRunWithPolicyNotStarted(t *testing.T) {
+	r := &disabledRunnable{mockRunnable: mockRunnable{name: "disabled"}}
+
+	var started, stopped int
+	s := newStateTestService(
+		WithOnStarted(func(Runnable) { started++ }),
+		WithOnStopped(func(Runnable) { stopped++ }),
+	)
+
+	assert.Nil(t, s.runWithPolicy(r), "must be nil")
+	assert.Equal(t, 0, started, "should never be running")
+	assert.Equal(t, 1, stopped, "should be equal")
+	assert.Equal(t, StateStopped, s.states.get(r).state, "should be equal")
+}
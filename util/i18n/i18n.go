@@ -0,0 +1,236 @@
+// Package i18n translates messages keyed by error keys (ErrorWithKey.Key(),
+// AppError.Code). English text in the code stays as the fallback
+package i18n
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+var defaultCatalog = NewCatalog()
+
+// Default returns the catalog used by the recover middleware
+func Default() *Catalog {
+	return defaultCatalog
+}
+
+type Catalog struct {
+	mu *sync.RWMutex
+	// lang => key => message
+	messages map[string]map[string]string
+}
+
+func NewCatalog() *Catalog {
+	return &Catalog{
+		mu:       new(sync.RWMutex),
+		messages: map[string]map[string]string{},
+	}
+}
+
+// Add messages of a language, existing keys are overridden
+func (c *Catalog) Add(lang string, messages map[string]string) {
+	lang = normalize(lang)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.messages[lang] == nil {
+		c.messages[lang] = map[string]string{}
+	}
+
+	for k, v := range messages {
+		c.messages[lang][k] = v
+	}
+}
+
+// LoadFile loads a flat key => message file, language is the file name.
+// Ex: vi.json, vi.yaml or en-US.yml
+func (c *Catalog) LoadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	messages := map[string]string{}
+
+	switch ext {
+	case ".json":
+		err = json.Unmarshal(data, &messages)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &messages)
+	default:
+		return fmt.Errorf("i18n file %s is not supported, use .json, .yaml or .yml", path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot parse i18n file %s: %s", path, err.Error())
+	}
+
+	c.Add(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), messages)
+	return nil
+}
+
+// LoadDir loads all .json, .yaml and .yml files of dir
+func (c *Catalog) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".json", ".yaml", ".yml":
+			if err := c.LoadFile(filepath.Join(dir, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Translate returns message of key in lang, then in its base language
+// (vi-VN => vi), otherwise fallback
+func (c *Catalog) Translate(lang, key, fallback string) string {
+	if key == "" || lang == "" {
+		return fallback
+	}
+
+	lang = normalize(lang)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if msg, ok := c.messages[lang][key]; ok {
+		return msg
+	}
+
+	if idx := strings.Index(lang, "-"); idx > 0 {
+		if msg, ok := c.messages[lang[:idx]][key]; ok {
+			return msg
+		}
+	}
+
+	return fallback
+}
+
+func (c *Catalog) Languages() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	langs := make([]string, 0, len(c.messages))
+	for lang := range c.messages {
+		langs = append(langs, lang)
+	}
+
+	sort.Strings(langs)
+	return langs
+}
+
+func (c *Catalog) hasLanguage(lang string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.messages[lang]
+	return ok
+}
+
+// Negotiate picks the best language of Accept-Language the catalog has,
+// empty if none
+func (c *Catalog) Negotiate(acceptLanguage string) string {
+	best, bestQ := "", 0.0
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		lang, q := parseQuality(part)
+		if lang == "" || q <= bestQ {
+			continue
+		}
+
+		if c.hasLanguage(lang) {
+			best, bestQ = lang, q
+			continue
+		}
+
+		if idx := strings.Index(lang, "-"); idx > 0 && c.hasLanguage(lang[:idx]) {
+			best, bestQ = lang[:idx], q
+		}
+	}
+
+	return best
+}
+
+func parseQuality(s string) (string, float64) {
+	parts := strings.Split(s, ";")
+	lang, q := normalize(parts[0]), 1.0
+
+	for _, p := range parts[1:] {
+		p = strings.TrimSpace(p)
+		if strings.HasPrefix(p, "q=") {
+			if v, err := strconv.ParseFloat(p[2:], 64); err == nil {
+				q = v
+			}
+		}
+	}
+
+	if lang == "*" {
+		return "", 0
+	}
+
+	return lang, q
+}
+
+// vi_VN, VI-vn => vi-vn
+func normalize(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+}
+
+type langKey struct{}
+
+func ContextWithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, langKey{}, lang)
+}
+
+// LanguageFromContext returns empty string if ctx has no language
+func LanguageFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	lang, _ := ctx.Value(langKey{}).(string)
+	return lang
+}
+
+type keyedError interface {
+	error
+	Key() string
+}
+
+// T translates key to the language of ctx with Default catalog
+func T(ctx context.Context, key, fallback string) string {
+	return defaultCatalog.Translate(LanguageFromContext(ctx), key, fallback)
+}
+
+// Error translates an error having Key(), ex: sdkcm.CustomError,
+// to the language of ctx. Its Error() is the fallback
+func Error(ctx context.Context, err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if ke, ok := err.(keyedError); ok {
+		return T(ctx, ke.Key(), err.Error())
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,41 @@
+package i18n
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "vi.json"), []byte(`{"ErrUserNotFound": "Không tìm thấy người dùng"}`), 0644), "must be nil")
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "ja.yaml"), []byte("ErrUserNotFound: ユーザーが見つかりません\n"), 0644), "must be nil")
+
+	c := NewCatalog()
+	assert.Nil(t, c.LoadDir(dir), "must be nil")
+	assert.Equal(t, []string{"ja", "vi"}, c.Languages(), "should be equal")
+
+	assert.Equal(t, "Không tìm thấy người dùng", c.Translate("vi-VN", "ErrUserNotFound", "user not found"), "should use base language")
+	assert.Equal(t, "user not found", c.Translate("fr", "ErrUserNotFound", "user not found"), "should fallback")
+	assert.Equal(t, "bad", c.Translate("vi", "ErrBad", "bad"), "should fallback")
+
+	assert.Equal(t, "ja", c.Negotiate("fr-FR, vi;q=0.5, ja;q=0.8"), "should be equal")
+	assert.Equal(t, "vi", c.Negotiate("vi-VN,en;q=0.9"), "should be equal")
+	assert.Equal(t, "", c.Negotiate("fr, *"), "should be empty")
+}
+
+type keyErr struct{}
+
+func (keyErr) Error() string { return "user not found" }
+func (keyErr) Key() string   { return "ErrUserNotFound" }
+
+func TestError(t *testing.T) {
+	Default().Add("vi", map[string]string{"ErrUserNotFound": "Không tìm thấy người dùng"})
+
+	ctx := ContextWithLanguage(context.Background(), "vi")
+	assert.Equal(t, "Không tìm thấy người dùng", Error(ctx, keyErr{}), "should be equal")
+	assert.Equal(t, "user not found", Error(context.Background(), keyErr{}), "should fallback")
+}
@@ -0,0 +1,125 @@
+// Package metrics registers Prometheus metrics (client_golang) of the service.
+// Every sample has the constant labels of its registry (ex: service and version)
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// Default buckets (in seconds) for latency histograms
+var DefBuckets = prometheus.DefBuckets
+
+var defaultRegistry = NewRegistry(nil)
+
+// Default returns the registry exposed by the service on /metrics
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Registry is a prometheus.Registry adding constant labels to samples when they are
+// gathered, so metrics created before the service is named (ex: package vars) have them
+type Registry struct {
+	*prometheus.Registry
+	mu          *sync.RWMutex
+	constLabels map[string]string
+}
+
+func NewRegistry(constLabels map[string]string) *Registry {
+	return &Registry{
+		Registry:    prometheus.NewRegistry(),
+		mu:          new(sync.RWMutex),
+		constLabels: constLabels,
+	}
+}
+
+// SetConstLabels sets labels added to every sample, ex: service and version
+func (r *Registry) SetConstLabels(labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.constLabels = labels
+}
+
+// register returns the registered collector with the same name,
+// it panics when the name is taken by a different kind or labels
+func (r *Registry) register(c prometheus.Collector) prometheus.Collector {
+	if err := r.Register(c); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if errors.As(err, &registered) {
+			return registered.ExistingCollector
+		}
+
+		panic(err)
+	}
+
+	return c
+}
+
+func (r *Registry) Counter(name, help string, labelNames ...string) *prometheus.CounterVec {
+	return r.register(prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labelNames)).(*prometheus.CounterVec)
+}
+
+func (r *Registry) Gauge(name, help string, labelNames ...string) *prometheus.GaugeVec {
+	return r.register(prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labelNames)).(*prometheus.GaugeVec)
+}
+
+// Histogram uses DefBuckets if buckets is empty
+func (r *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) *prometheus.HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+
+	opts := prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}
+	return r.register(prometheus.NewHistogramVec(opts, labelNames)).(*prometheus.HistogramVec)
+}
+
+// Gather implements prometheus.Gatherer, it adds the constant labels
+// which are not already set by the metric
+func (r *Registry) Gather() ([]*dto.MetricFamily, error) {
+	families, err := r.Registry.Gather()
+
+	r.mu.RLock()
+	constLabels := r.constLabels
+	r.mu.RUnlock()
+
+	if len(constLabels) == 0 {
+		return families, err
+	}
+
+	for _, family := range families {
+		for _, m := range family.Metric {
+			m.Label = withConstLabels(m.Label, constLabels)
+		}
+	}
+
+	return families, err
+}
+
+func withConstLabels(pairs []*dto.LabelPair, constLabels map[string]string) []*dto.LabelPair {
+	set := map[string]bool{}
+	for _, p := range pairs {
+		set[p.GetName()] = true
+	}
+
+	for name, value := range constLabels {
+		if !set[name] {
+			pairs = append(pairs, &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)})
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].GetName() < pairs[j].GetName() })
+	return pairs
+}
+
+// Handler serves the registry for Prometheus scrapers
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGatherConstLabels(t *testing.T) {
+	r := NewRegistry(nil)
+
+	r.Counter("jobs_total", "Jobs done.", "state").WithLabelValues("ok").Add(2)
+	r.Counter("jobs_total", "Jobs done.", "state").WithLabelValues("fail").Inc()
+	r.Gauge("queue_size", "Queue size.", "service").WithLabelValues("worker").Set(3)
+	r.Histogram("latency_seconds", "Latency.", []float64{0.1, 1}).WithLabelValues().Observe(0.5)
+
+	// metrics created before the service is named have its labels
+	r.SetConstLabels(map[string]string{"service": "demo"})
+
+	err := testutil.GatherAndCompare(r, strings.NewReader(`# HELP jobs_total Jobs done.
+# TYPE jobs_total counter
+jobs_total{service="demo",state="fail"} 1
+jobs_total{service="demo",state="ok"} 2
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{service="demo",le="0.1"} 0
+latency_seconds_bucket{service="demo",le="1"} 1
+latency_seconds_bucket{service="demo",le="+Inf"} 1
+latency_seconds_sum{service="demo"} 0.5
+latency_seconds_count{service="demo"} 1
+# HELP queue_size Queue size.
+# TYPE queue_size gauge
+queue_size{service="worker"} 3
+`))
+	assert.Nil(t, err, "must be nil")
+
+	assert.Panics(t, func() { r.Gauge("jobs_total", "Jobs done.", "state") }, "should panic on conflict")
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry(map[string]string{"service": "demo"})
+	r.RegisterRuntimeMetrics()
+	r.RegisterRuntimeMetrics()
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code, "should be equal")
+	assert.Contains(t, w.Body.String(), `go_goroutines{service="demo"}`, "should have runtime metrics")
+}
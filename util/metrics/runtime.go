@@ -0,0 +1,11 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// RegisterRuntimeMetrics adds Go runtime and process metrics to the registry
+func (r *Registry) RegisterRuntimeMetrics() {
+	r.register(collectors.NewGoCollector())
+	r.register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+}
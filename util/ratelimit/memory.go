@@ -0,0 +1,179 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Stale keys are removed at most once per this interval
+const memorySweepInterval = time.Minute
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+type window struct {
+	start time.Time
+	prev  int64
+	curr  int64
+}
+
+type memoryLimiter struct {
+	cfg       Config
+	mu        *sync.Mutex
+	buckets   map[string]*bucket
+	windows   map[string]*window
+	sweptAt   time.Time
+	timeNowFn func() time.Time
+}
+
+// NewMemory creates an in-process limiter, each instance of the service has its own counters.
+// It returns an error when cfg is invalid, ex: limit or window is not positive
+func NewMemory(cfg Config) (Limiter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &memoryLimiter{
+		cfg:       cfg,
+		mu:        new(sync.Mutex),
+		buckets:   map[string]*bucket{},
+		windows:   map[string]*window{},
+		timeNowFn: time.Now,
+	}, nil
+}
+
+func (l *memoryLimiter) Allow(_ context.Context, key string) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.timeNowFn()
+	l.sweep(now)
+
+	if l.cfg.Algorithm == TokenBucket {
+		return l.allowTokenBucket(key, now), nil
+	}
+
+	return l.allowSlidingWindow(key, now), nil
+}
+
+func (l *memoryLimiter) allowTokenBucket(key string, now time.Time) Result {
+	capacity := float64(l.cfg.burst())
+	rate := float64(l.cfg.Limit) / float64(l.cfg.Window) // tokens per nanosecond
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updatedAt: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+float64(now.Sub(b.updatedAt))*rate)
+	b.updatedAt = now
+
+	return takeToken(&b.tokens, capacity, rate)
+}
+
+func takeToken(tokens *float64, capacity, rate float64) Result {
+	res := Result{Limit: int(capacity)}
+
+	if *tokens >= 1 {
+		*tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((1 - *tokens) / rate))
+	}
+
+	res.Remaining = int(*tokens)
+	res.ResetAfter = time.Duration(math.Ceil((capacity - *tokens) / rate))
+
+	return res
+}
+
+func (l *memoryLimiter) allowSlidingWindow(key string, now time.Time) Result {
+	start := windowStart(now, l.cfg.Window)
+
+	w, ok := l.windows[key]
+	if !ok {
+		w = &window{start: start}
+		l.windows[key] = w
+	}
+
+	switch {
+	case start.Equal(w.start):
+	case start.Sub(w.start) == l.cfg.Window:
+		w.start, w.prev, w.curr = start, w.curr, 0
+	default:
+		w.start, w.prev, w.curr = start, 0, 0
+	}
+
+	res := slidingResult(w.prev, w.curr, now, l.cfg)
+	if res.Allowed {
+		w.curr++
+	}
+
+	return res
+}
+
+func slidingResult(prev, curr int64, now time.Time, cfg Config) Result {
+	count := slidingCount(prev, curr, now, cfg.Window)
+	nextWindow := windowStart(now, cfg.Window).Add(cfg.Window)
+
+	res := Result{
+		Allowed:    count+1 <= float64(cfg.Limit),
+		Limit:      cfg.Limit,
+		ResetAfter: nextWindow.Sub(now),
+	}
+
+	if res.Allowed {
+		res.Remaining = int(float64(cfg.Limit) - count - 1)
+	} else {
+		res.RetryAfter = retryAfterSliding(prev, curr, now, cfg)
+	}
+
+	return res
+}
+
+// retryAfterSliding finds when the weight of the previous window drops enough,
+// or waits for the next window when the current one is full
+func retryAfterSliding(prev, curr int64, now time.Time, cfg Config) time.Duration {
+	nextWindow := windowStart(now, cfg.Window).Add(cfg.Window)
+
+	if curr+1 > int64(cfg.Limit) || prev == 0 {
+		return nextWindow.Sub(now)
+	}
+
+	// prev * (window - elapsed) / window + curr + 1 <= limit
+	elapsed := float64(cfg.Window) * (1 - float64(int64(cfg.Limit)-curr-1)/float64(prev))
+	at := windowStart(now, cfg.Window).Add(time.Duration(math.Ceil(elapsed)))
+
+	if wait := at.Sub(now); wait > 0 {
+		return wait
+	}
+
+	return time.Millisecond
+}
+
+func (l *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.sweptAt) < memorySweepInterval {
+		return
+	}
+
+	l.sweptAt = now
+	start := windowStart(now, l.cfg.Window)
+	rate := float64(l.cfg.Limit) / float64(l.cfg.Window)
+
+	for key, b := range l.buckets {
+		if b.tokens+float64(now.Sub(b.updatedAt))*rate >= float64(l.cfg.burst()) {
+			delete(l.buckets, key)
+		}
+	}
+
+	for key, w := range l.windows {
+		if start.Sub(w.start) > l.cfg.Window {
+			delete(l.windows, key)
+		}
+	}
+}
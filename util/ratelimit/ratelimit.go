@@ -0,0 +1,76 @@
+// Package ratelimit limits how many times a key (client IP, user...)
+// can do something in a window, state is kept in memory or in redis
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// Tokens refill continuously, Burst tokens can be spent at once
+	TokenBucket = "token-bucket"
+	// Weighted count of the previous and current fixed windows,
+	// smoother than fixed windows and cheaper than a log of requests
+	SlidingWindow = "sliding-window"
+)
+
+type Config struct {
+	Algorithm string
+	// Allowed requests per Window
+	Limit  int
+	Window time.Duration
+	// Capacity of token bucket, Limit is used if 0
+	Burst int
+}
+
+func (cfg Config) Validate() error {
+	switch cfg.Algorithm {
+	case TokenBucket, SlidingWindow:
+	default:
+		return fmt.Errorf("rate limit algorithm %q is not supported, use: %s | %s", cfg.Algorithm, TokenBucket, SlidingWindow)
+	}
+
+	if cfg.Limit <= 0 || cfg.Window <= 0 || cfg.Burst < 0 {
+		return fmt.Errorf("rate limit must have positive limit and window")
+	}
+
+	return nil
+}
+
+func (cfg Config) burst() int {
+	if cfg.Burst > 0 {
+		return cfg.Burst
+	}
+
+	return cfg.Limit
+}
+
+type Result struct {
+	Allowed bool
+	// Limit of the window (or bucket capacity)
+	Limit     int
+	Remaining int
+	// Time until the limit is fully reset
+	ResetAfter time.Duration
+	// Time to wait before retrying, 0 when allowed
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// windowStart returns start of the fixed window containing now
+func windowStart(now time.Time, window time.Duration) time.Time {
+	return now.Truncate(window)
+}
+
+// slidingCount weights count of the previous window by its overlap with the sliding window
+func slidingCount(prev, curr int64, now time.Time, window time.Duration) float64 {
+	elapsed := now.Sub(windowStart(now, window))
+	weight := float64(window-elapsed) / float64(window)
+
+	return float64(prev)*weight + float64(curr)
+}
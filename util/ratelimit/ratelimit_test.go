@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter, err := NewMemory(Config{Algorithm: TokenBucket, Limit: 2, Window: time.Second})
+	assert.Nil(t, err, "must be nil")
+	l := limiter.(*memoryLimiter)
+	l.timeNowFn = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		res, err := l.Allow(context.Background(), "ip")
+		assert.Nil(t, err, "must be nil")
+		assert.True(t, res.Allowed, "should be allowed")
+	}
+
+	res, _ := l.Allow(context.Background(), "ip")
+	assert.False(t, res.Allowed, "should be limited")
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter, "should be equal")
+
+	res, _ = l.Allow(context.Background(), "other")
+	assert.True(t, res.Allowed, "should be allowed")
+
+	now = now.Add(500 * time.Millisecond)
+	res, _ = l.Allow(context.Background(), "ip")
+	assert.True(t, res.Allowed, "should be allowed")
+}
+
+func TestSlidingWindow(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter, err := NewMemory(Config{Algorithm: SlidingWindow, Limit: 4, Window: time.Minute})
+	assert.Nil(t, err, "must be nil")
+	l := limiter.(*memoryLimiter)
+	l.timeNowFn = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		res, _ := l.Allow(context.Background(), "ip")
+		assert.True(t, res.Allowed, "should be allowed")
+		assert.Equal(t, 3-i, res.Remaining, "should be equal")
+	}
+
+	res, _ := l.Allow(context.Background(), "ip")
+	assert.False(t, res.Allowed, "should be limited")
+
+	// previous window still weights 4 * 0.75 = 3
+	now = now.Add(75 * time.Second)
+	res, _ = l.Allow(context.Background(), "ip")
+	assert.True(t, res.Allowed, "should be allowed")
+
+	res, _ = l.Allow(context.Background(), "ip")
+	assert.False(t, res.Allowed, "should be limited")
+	assert.Equal(t, 15*time.Second, res.RetryAfter, "should be equal")
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.Nil(t, Config{Algorithm: SlidingWindow, Limit: 1, Window: time.Second}.Validate(), "must be nil")
+	assert.NotNil(t, Config{Algorithm: "fixed", Limit: 1, Window: time.Second}.Validate(), "should be an error")
+	assert.NotNil(t, Config{Algorithm: TokenBucket, Window: time.Second}.Validate(), "should be an error")
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	tests := []Config{
+		{Algorithm: TokenBucket, Limit: 0, Window: time.Second},
+		{Algorithm: TokenBucket, Limit: 1, Window: 0},
+		{Algorithm: SlidingWindow, Limit: -1, Window: time.Minute},
+		{Algorithm: SlidingWindow, Limit: 1, Window: time.Minute, Burst: -1},
+		{Algorithm: "leaky-bucket", Limit: 1, Window: time.Minute},
+	}
+
+	for _, cfg := range tests {
+		_, err := NewMemory(cfg)
+		assert.NotNil(t, err, "%+v must be rejected", cfg)
+
+		_, err = NewRedis(nil, "", cfg)
+		assert.NotNil(t, err, "%+v must be rejected", cfg)
+	}
+
+	_, err := NewRedis(nil, "", Config{Algorithm: TokenBucket, Limit: 1, Window: time.Second})
+	assert.NotNil(t, err, "must not be nil without client")
+}
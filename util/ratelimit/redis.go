@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// KEYS[1]: bucket. ARGV: capacity, rate (tokens per ms), now (ms), ttl (ms).
+// Returns tokens after taking one (or not) as string to keep the fraction
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], ARGV[4])
+return {allowed, tostring(tokens)}
+`)
+
+// KEYS[1]: previous window, KEYS[2]: current window.
+// ARGV: limit, weight of previous window, ttl (ms).
+// Returns counts before this request
+var slidingWindowScript = redis.NewScript(`
+local prev = tonumber(redis.call("GET", KEYS[1]) or "0")
+local curr = tonumber(redis.call("GET", KEYS[2]) or "0")
+if prev * tonumber(ARGV[2]) + curr + 1 <= tonumber(ARGV[1]) then
+	redis.call("INCR", KEYS[2])
+	redis.call("PEXPIRE", KEYS[2], ARGV[3])
+end
+return {prev, curr}
+`)
+
+type redisLimiter struct {
+	cfg       Config
+	client    *redis.Client
+	keyPrefix string
+	timeNowFn func() time.Time
+}
+
+// NewRedis creates a limiter shared by all instances of the service,
+// client is usually got from sdkredis: sc.MustGet("redis").(*redis.Client).
+// It returns an error when cfg is invalid, ex: limit or window is not positive
+func NewRedis(client *redis.Client, keyPrefix string, cfg Config) (Limiter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if client == nil {
+		return nil, errors.New("rate limit needs a redis client")
+	}
+
+	if keyPrefix == "" {
+		keyPrefix = "ratelimit"
+	}
+
+	return &redisLimiter{
+		cfg:       cfg,
+		client:    client,
+		keyPrefix: keyPrefix,
+		timeNowFn: time.Now,
+	}, nil
+}
+
+func (l *redisLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	now := l.timeNowFn()
+	client := l.client.WithContext(ctx)
+
+	if l.cfg.Algorithm == TokenBucket {
+		return l.allowTokenBucket(client, key, now)
+	}
+
+	return l.allowSlidingWindow(client, key, now)
+}
+
+func (l *redisLimiter) allowTokenBucket(client *redis.Client, key string, now time.Time) (Result, error) {
+	capacity := float64(l.cfg.burst())
+	rate := float64(l.cfg.Limit) / float64(l.cfg.Window) // per nanosecond
+	ttl := time.Duration(capacity/rate) + time.Second
+
+	v, err := tokenBucketScript.Run(client,
+		[]string{fmt.Sprintf("%s:tb:%s", l.keyPrefix, key)},
+		capacity, rate*float64(time.Millisecond), now.UnixNano()/int64(time.Millisecond), ttl.Milliseconds(),
+	).Result()
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := v.([]interface{})
+	if !ok || len(values) != 2 {
+		return Result{}, fmt.Errorf("unexpected rate limit script result: %v", v)
+	}
+
+	tokens, err := strconv.ParseFloat(fmt.Sprint(values[1]), 64)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// token has been taken by the script when allowed
+	if values[0] == int64(1) {
+		tokens++
+	}
+
+	return takeToken(&tokens, capacity, rate), nil
+}
+
+func (l *redisLimiter) allowSlidingWindow(client *redis.Client, key string, now time.Time) (Result, error) {
+	start := windowStart(now, l.cfg.Window)
+	weight := float64(l.cfg.Window-now.Sub(start)) / float64(l.cfg.Window)
+	windowKey := func(t time.Time) string {
+		return fmt.Sprintf("%s:sw:%s:%d", l.keyPrefix, key, t.UnixNano()/int64(time.Millisecond))
+	}
+
+	v, err := slidingWindowScript.Run(client,
+		[]string{windowKey(start.Add(-l.cfg.Window)), windowKey(start)},
+		l.cfg.Limit, weight, (2 * l.cfg.Window).Milliseconds(),
+	).Result()
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := v.([]interface{})
+	if !ok || len(values) != 2 {
+		return Result{}, fmt.Errorf("unexpected rate limit script result: %v", v)
+	}
+
+	prev, _ := values[0].(int64)
+	curr, _ := values[1].(int64)
+
+	return slidingResult(prev, curr, now, l.cfg), nil
+}
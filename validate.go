@@ -0,0 +1,69 @@
+// Copyright (c) 2019, Viet Tran, 200Lab Team.
+
+package goservice
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError aggregates config problems of all components
+type ValidationError struct {
+	Errors []error
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i := range e.Errors {
+		msgs[i] = "  - " + e.Errors[i].Error()
+	}
+
+	return fmt.Sprintf("invalid config (%d errors):\n%s", len(e.Errors), strings.Join(msgs, "\n"))
+}
+
+// validate checks config of the service and every component implementing
+// Validator, all problems are returned together
+func (s *service) validate() error {
+	var errs []error
+
+	add := func(name string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", name, err.Error()))
+		}
+	}
+
+	switch s.outEnvFormat {
+	case ConfigFormatEnv, ConfigFormatYAML, ConfigFormatJSON, ConfigFormatTOML:
+	default:
+		add("service", ErrConfigFormatNotSupported)
+	}
+
+	if s.shutdownTimeout < 0 {
+		add("service", fmt.Errorf("shutdown-timeout must not be negative"))
+	}
+
+	for _, subService := range s.subServices {
+		if v, ok := subService.(Validator); ok {
+			add(subService.Name(), v.Validate())
+		}
+	}
+
+	for _, prefix := range s.initPrefixes {
+		if v, ok := s.initServices[prefix].(Validator); ok {
+			add(s.initServices[prefix].Name(), v.Validate())
+		}
+	}
+
+	add("service", s.validateMetrics())
+	add("service", s.validateI18nDir())
+
+	if _, err := s.sortInitServices(); err != nil {
+		add("service", err)
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
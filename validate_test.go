@@ -0,0 +1,32 @@
+package goservice
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitReturnsAllConfigErrors(t *testing.T) {
+	var s *service
+
+	assert.NotPanics(t, func() {
+		s = newIsolatedService(t, []string{
+			"-shutdown-timeout=-1",
+			"-outenv-format=xml",
+			"-gin-error-format=xml",
+			"-internal-error-format=html",
+		}, WithName("x"), WithHttpServer("internal"))
+	}, "New must not exit on invalid config")
+
+	err := s.Init()
+
+	var validationErr *ValidationError
+	assert.True(t, errors.As(err, &validationErr), "should be a ValidationError")
+	assert.Equal(t, 4, len(validationErr.Errors), "should report every problem")
+	assert.Contains(t, err.Error(), "shutdown-timeout", "should contain service error")
+	assert.Contains(t, err.Error(), `"xml"`, "should contain gin error")
+	assert.Contains(t, err.Error(), `"html"`, "should contain internal server error")
+
+	assert.Equal(t, err, s.Start(), "Start must not run with invalid config")
+}